@@ -0,0 +1,113 @@
+package grun
+
+import (
+	"os"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+// EnvErrorDisplay defines the environment variable that overrides the App
+// ErrorDisplay: print, dialog or panel.
+var EnvErrorDisplay = "GRUN_ERRORS"
+
+// Error dialog texts.
+var (
+	TxtErrDialogTitle = "Error"
+	TxtErrDialogClose = "Close"
+)
+
+// ErrorDisplay defines how errors from Actions are presented in window mode.
+// Errors are always returned by Run. Headless, they are only printed.
+type ErrorDisplay int
+
+// Error displays.
+const (
+	DisplayPrint  ErrorDisplay = iota // Print errors when Run returns (default).
+	DisplayDialog                     // Show a modal dialog, and quit when closed.
+	DisplayPanel                      // Show the errors in the window and keep running.
+)
+
+// displayNames lists the ErrorDisplay values for EnvErrorDisplay.
+var displayNames = map[string]ErrorDisplay{
+	"print":  DisplayPrint,
+	"dialog": DisplayDialog,
+	"panel":  DisplayPanel,
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// errorDisplay returns the ErrorDisplay, overridden by the environment.
+func (app *App) errorDisplay() ErrorDisplay {
+	if display, ok := displayNames[os.Getenv(EnvErrorDisplay)]; ok {
+		return display
+	}
+	return app.ErrorDisplay
+}
+
+// showError presents the error according to the ErrorDisplay.
+// Returns true if the error was shown, then the dialog quits when closed.
+func (app *App) showError(e error) bool {
+	if app.Headless || app.App == nil {
+		return false
+	}
+	errs, ok := e.(Errors)
+	if !ok {
+		errs = Errors{e}
+	}
+	switch app.errorDisplay() {
+	case DisplayDialog:
+		app.errorDialog(errs)
+		return true
+
+	case DisplayPanel:
+		app.errorPanel(errs)
+		return true
+	}
+	return false
+}
+
+// errorDialog shows the errors in a modal dialog, transient for Win.
+// The application quits when it's closed.
+// The gotk4 bindings have no gtk.MessageDialog constructor: a gtk.Dialog is
+// used with the errors widget in its content area, like a message area.
+func (app *App) errorDialog(errs Errors) {
+	dialog := gtk.NewDialog()
+	dialog.SetApplication(app.App)
+	dialog.SetTitle(TxtErrDialogTitle)
+	dialog.SetModal(true)
+	if app.Win != nil {
+		dialog.SetTransientFor(&app.Win.Window)
+	}
+	dialog.ContentArea().Append(errs.Widget()) // With copy all button.
+	dialog.AddButton(TxtErrDialogClose, int(gtk.ResponseClose))
+	dialog.Connect("response", func(_ int) { dialog.Destroy() })
+
+	dialog.Connect("destroy", app.quit)
+	dialog.Show()
+}
+
+// errorPanel shows the errors in Win, above its content, or in a new window.
+func (app *App) errorPanel(errs Errors) {
+	if app.Win == nil {
+		app.PackWindow(func() Window { return Window{Widget: errs.Widget()} })
+		return
+	}
+	box := gtk.NewBox(gtk.OrientationVertical, 0)
+	box.Append(errs.Widget())
+	if child := app.Win.Child(); child != nil {
+		app.Win.SetChild(nil)
+		box.Append(child)
+	}
+	app.Win.SetChild(box)
+}
+
+//
+//------------------------------------------------------------------[ PARAMS ]--
+
+// SetErrorDisplay creates a Param that sets how errors from Actions are
+// presented in window mode. EnvErrorDisplay overrides it.
+// Usable at any moment.
+func SetErrorDisplay(display ErrorDisplay) Param {
+	return func(app *App) { app.ErrorDisplay = display }
+}
@@ -0,0 +1,42 @@
+package grun
+
+import "fmt"
+
+// FmtErrExecPath annotates the errors of Actions in lists with ErrorCollect.
+// The path matches Validate and Plan: [index] in lists, OnRun for the callback.
+var FmtErrExecPath = "grun.Exec(%s %s): %w" // Format: path, name, error
+
+// ErrorPolicy defines how Exec handles the errors of Actions in lists and maps.
+type ErrorPolicy int
+
+// Error policies.
+const (
+	ErrorDefault  ErrorPolicy = iota // Lists stop on the first error, maps launch all entries (default).
+	ErrorFailFast                    // Lists and maps stop on the first error.
+	ErrorCollect                     // Lists and maps launch all Actions and return an Errors list.
+)
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// wrapErrors appends the error to the list, annotated with format and args.
+// Errors lists are flattened, each error is annotated.
+func (e *Errors) wrapErrors(err error, format string, args ...interface{}) {
+	if list, ok := err.(Errors); ok {
+		for _, one := range list {
+			e.wrapErrors(one, format, args...)
+		}
+		return
+	}
+	e.Append(fmt.Errorf(format, append(args, err)...))
+}
+
+//
+//------------------------------------------------------------------[ PARAMS ]--
+
+// SetErrorPolicy creates a Param that sets how Exec handles the errors of
+// Actions in lists and maps.
+// Usable at any moment.
+func SetErrorPolicy(policy ErrorPolicy) Param {
+	return func(app *App) { app.ErrorPolicy = policy }
+}
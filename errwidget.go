@@ -0,0 +1,115 @@
+package grun
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	externglib "github.com/diamondburned/gotk4/pkg/core/glib"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+// Format located errors messages.
+var (
+	FmtErrLocated     = "%s:%d: %v"    // Format: file, line, error
+	FmtErrLocatedCol  = "%s:%d:%d: %v" // Format: file, line, column, error
+	FmtErrLocation    = "%s:%d"        // Format: file, line
+	FmtErrLocationCol = "%s:%d:%d"     // Format: file, line, column
+)
+
+// Errors widget settings.
+var (
+	TxtErrCopyAll   = "Copy all"
+	IconErr         = "dialog-error-symbolic"
+	IconWarn        = "dialog-warning-symbolic"
+	ErrWidgetHeight = 200 // Minimum height of the scrollable list.
+)
+
+// LocatedError defines an error with its source location, like a line in an
+// UI file loaded with gtk.Builder. The column is optional.
+type LocatedError struct {
+	File   string
+	Line   int
+	Column int
+	Err    error
+}
+
+// Error returns the error message prefixed by its location.
+func (e *LocatedError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf(FmtErrLocatedCol, e.File, e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf(FmtErrLocated, e.File, e.Line, e.Err)
+}
+
+// Unwrap returns the located error.
+func (e *LocatedError) Unwrap() error { return e.Err }
+
+// Location returns the formatted location: file:line or file:line:column.
+func (e *LocatedError) Location() string {
+	if e.Column > 0 {
+		return fmt.Sprintf(FmtErrLocationCol, e.File, e.Line, e.Column)
+	}
+	return fmt.Sprintf(FmtErrLocation, e.File, e.Line)
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// widget returns a scrollable list of the errors, with a copy all button.
+func (e Errors) widget() gtk.Widgetter {
+	list := gtk.NewListBox()
+	list.SetSelectionMode(gtk.SelectionNone)
+	for _, err := range e {
+		if warnings, ok := err.(Warnings); ok {
+			for _, warn := range warnings {
+				list.Append(errorRow(IconWarn, warn))
+			}
+			continue
+		}
+		list.Append(errorRow(IconErr, err))
+	}
+
+	scroll := gtk.NewScrolledWindow()
+	scroll.SetChild(list)
+	scroll.SetVExpand(true)
+	scroll.SetMinContentHeight(ErrWidgetHeight)
+
+	copyAll := gtk.NewButtonWithLabel(TxtErrCopyAll)
+	copyAll.SetHAlign(gtk.AlignEnd)
+	copyAll.Connect("clicked", func() {
+		copyAll.Clipboard().Set(externglib.NewValue(fmt.Sprintf(FmtErrLabel, e)))
+	})
+
+	box := gtk.NewBox(gtk.OrientationVertical, 6)
+	box.Append(scroll)
+	box.Append(copyAll)
+	return box
+}
+
+// errorRow returns a row with the icon, the location if any, and the
+// selectable error message (with the location only in its label).
+func errorRow(icon string, err error) gtk.Widgetter {
+	row := gtk.NewBox(gtk.OrientationHorizontal, 6)
+	row.Append(gtk.NewImageFromIconName(icon))
+
+	text := err.Error()
+	var located *LocatedError
+	if errors.As(err, &located) {
+		location := gtk.NewLabel(located.Location())
+		location.AddCSSClass("dim-label")
+		location.SetSelectable(true)
+		row.Append(location)
+		if located.Err != nil { // Keep the annotations, without the location.
+			text = strings.Replace(text, located.Error(), located.Err.Error(), 1)
+		}
+	}
+
+	message := gtk.NewLabel(text)
+	message.SetSelectable(true)
+	message.SetWrap(true)
+	message.SetXAlign(0)
+	message.SetHExpand(true)
+	row.Append(message)
+	return row
+}
@@ -7,7 +7,7 @@
 // Actions can be any function with allowed arguments and returns, or lists of
 // functions ([]func, map[string]func). Recursion is possible (lists in lists).
 //
-//   - Arguments:  none, App
+//   - Arguments:  none, App, context.Context, or resolved with Provide
 //   - Returns:    none, gtk.Widgetter, error, Errors,
 //                 (gtk.Widgetter, error), (gtk.Widgetter, Errors)
 //
@@ -70,7 +70,6 @@
 //
 // Todo
 //
-//   - App.App open signal to open files from the command line or gui.
 //   - ForceWindowInSingleTest Param: Need to detect if we're running a single
 //     or package test to auto-toggle the show window.
 //
@@ -85,9 +84,6 @@
 // feedback will be really appreciated.
 //
 // Options (ideas possible to implement):
-//   - Change OnStart callback to return an error. I feel this will mostly be used
-//     to LoadConfig() and/or InitDB() so an error/exit management would be nice.
-//   - MultiWindow flag: Allow any widget provided to open a window (at startup or later)
 //   - Rename Run to Go ?
 //   - Package name ideas:
 //      -grun      Go/Gtk Run          My best candidate so far. Run is the package main call.
@@ -148,17 +144,36 @@
 //   func(*App) gtk.Widgetter           // To act on App or Win object.
 //   func(*App) (gtk.Widgetter, error)  // ...
 //
+// With widget and window settings (name, title, size).
+//   Window                             // Widget with its window settings.
+//   func() Window                      // ...
+//   func(*App) Window                  // ...
+//   func(*App) (Window, error)         // ...
+//
 // Headless.
 //   func()                   // Simple func or closure.
 //   func() error             // With error testing.
 //   func(*App)               // To act on App or Win object.
 //   func(*App) error         // ...
+//   ActionFunc               // ...
 //   func() func(*App)        // In case the Action is wrapped.
+//   func(*gtk.Application)   // The application callback type.
+//
+// With context, cancelled when the application is closing.
+//   func(context.Context)                                  // Headless.
+//   func(context.Context) error                            // ...
+//   func(context.Context, *App)                            // ...
+//   func(context.Context, *App) error                      // ...
+//   func(context.Context) gtk.Widgetter                    // With widget.
+//   func(context.Context) (gtk.Widgetter, error)           // ...
+//   func(context.Context, *App) (gtk.Widgetter, error)     // ...
 //
 // Lists.
 //   []interface{}            // Recursive list of any handled type.
 //   map[string]interface{}:  // Warning, execution order from a map is random.
 //                            // This is mostly for tests and serial queuing.
+//                            // Unless SortedMaps is set.
+//   OrderedActions           // Named Actions in the list order.
 //
 // String as label window (for tests)
 //   string                   // Display a string.
@@ -167,7 +182,17 @@
 //
 // Errors
 //   error                    // An error stops Run.
-//   Errors                   // Or multiple errors.
+//   Errors                   // Or multiple errors (errors.Is and As compatible).
+//   ExitWith(code, err)      // An error that sets the exit code (ExitError).
+//   Warn(err...)             // Warnings, collected without stopping.
+//
+// More types can be registered with RegisterActionType and an Adapter. The
+// types above are its default registrations.
+//
+// Functions with other parameters are accepted when their returns match a
+// type above: parameters are resolved by type from the App (*gtk.Application,
+// *gtk.ApplicationWindow...) and the values added with Provide. Run checks
+// them with the values provided before it starts.
 //
 //
 // Callbacks
@@ -177,7 +202,13 @@
 //
 // They run in this order:
 //
+//   - Options          Optional. Command line options set their Go targets.
+//   - OnCommandLine    Optional. Handles the command line, then activates OnRun,
+//                      or OnOpen with the files arguments.
 //   - OnInit           Optional (logger, config and DB init for example)
+//                      If errors are returned, Stop: OnRun is skipped.
+//   - Stages           Optional. Named startup steps in dependency order.
+//                      If errors are returned, Stop: OnRun is skipped.
 //   - OnRun            Where all the work is done, and/or in the Run arguments.
 //     - Exec           Launch Actions.
 //                      If an Action can create a widget:
@@ -185,7 +216,14 @@
 //                        Create the widget.
 //                        Pack the widget if it's not nil and show the window.
 //                      If errors are returned, Stop.
+//   - OnOpen           Optional. Replaces OnRun when files are provided (command
+//                      line or gui). Returns Actions launched with Exec.
+//   - OnRemote         Optional. Called in the primary instance when the unique
+//                      application is launched again. Returns Actions launched
+//                      with Exec, the other instance exits with the ExitCode.
+//                      Its files arguments are then forwarded to OnOpen.
 //   - ..........       Application running........
+//   - Stages Stop      Optional. Stages started are stopped in reverse order.
 //   - OnStop           Optional.
 //
 //
@@ -194,22 +232,58 @@
 //  - Actions set in OnRun are called before those provided in the Run call to
 //    allow global actions before local actions
 //  - Only one window will be created with the first valid widget found (so
-//    there will be something to put inside).
-//  - The returned exit code is the first positive between GtkExitCode and
-//    GoExitCode (use the ExitCode method for GoExitCode).
+//    there will be something to put inside). Unless MultiWindow is set, to
+//    open a window for each widget. Windows are registered by name (Window).
+//  - Next widgets are dropped by default. PackPolicy can append them in a
+//    box, stack or notebook page, a new window, or return an error.
+//  - Lists stop on the first error while maps launch all their entries.
+//    ErrorPolicy can stop both, or launch everything and collect the errors
+//    annotated with the Action index or name.
+//  - In window mode, ErrorDisplay can show errors in a dialog before quitting,
+//    or in a window panel to keep running. The GRUN_ERRORS environment
+//    variable overrides it: print, dialog or panel.
+//  - Warnings returned by Actions (Warn), or inside Errors, are collected
+//    without stopping Exec. Run prints them at the end, and WarningBar shows
+//    them at the top of the window.
+//  - Errors.Widget lists errors and warnings with icons, selectable texts and
+//    a copy all button. LocatedError adds source locations, like lines of UI
+//    files loaded with gtk.Builder.
+//  - Logf replaces the printed messages. The gruntest package uses it to run
+//    Apps in tests with RunT: errors fail the test, messages use t.Log.
+//  - GTK must run on one OS thread. In tests, TestMain runs the Apps on its
+//    locked thread, serialised, so tests can be parallel. TestMainWindows hosts
+//    them in a single application to run their windows concurrently.
+//  - Actions are validated before Run starts the application: unsupported
+//    types are all reported with their path, and nothing is launched.
+//  - DryRun prints the Actions plan (order, kind, function names and
+//    windows) without creating the application. See Plan.
+//  - Panics in Actions are recovered as PanicError, shown like other errors
+//    (see ErrorDisplay). RePanic disables the recovery for debugging.
+//  - Middlewares added with Use wrap every Action launched by Exec, including
+//    Actions inside lists (logging, timing, tracing...).
+//  - RunResult returns the detailed result: exit codes, errors, failed Action,
+//    lifecycle phases duration, named Actions results...
+//  - On errors, the returned exit code is 1, or the code of the first
+//    ExitError found in the errors. Otherwise, it is the first positive
+//    between GtkExitCode and GoExitCode (use the ExitCode method for
+//    GoExitCode).
 //  - The returned exit code can be used with os.Exit but that prevents any
 //    defer calls from running. Use at your own risks.
 //
 package grun
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"time"
+	"unsafe"
 
+	externglib "github.com/diamondburned/gotk4/pkg/core/glib"
 	"github.com/diamondburned/gotk4/pkg/gio/v2"
 	"github.com/diamondburned/gotk4/pkg/gtk/v4"
 )
@@ -219,7 +293,8 @@ var (
 	FmtErrExec        = "grun.Exec(%s): %w"               // Format: name, error
 	FmtErrRun         = "grun.Run: %s"                    // Format: error
 	FmtErrTypeUnknown = "grun exec func type unknown: %T" // Format: interface{}
-	FmtErrLabel       = "errors:\n%s"                     // Format: error1\nerror2\nerror3...
+	FmtErrLabel       = "errors:\n%s"                     // Format: error1\nerror2\nerror3... Copied by Errors.Widget.
+	FmtErrExitCode    = "exit code %d"                    // Format: code
 	TxtErrNoWidget    = "grun.Errors.Widget called without widget"
 )
 
@@ -239,30 +314,69 @@ type Param func(*App)
 // App defines application settings to run a GTK application.
 type App struct {
 	// App and Window settings.
-	ID        string               // Format: "org.gtk.example"
-	Title     string               // Window title
-	Width     int                  // Window width
-	Height    int                  // Window height
-	Args      []string             // GTK command line arguments: https://www.systutorials.com/docs/linux/man/7-gtk-options/
-	Flags     gio.ApplicationFlags // See flags: https://pkg.go.dev/github.com/diamondburned/gotk4/pkg/gio/v2#ApplicationFlags
-	Headless  bool                 // Force without window
-	GuessName bool                 // Auto set ID and Title if empty
-	FmtID     string
-	FmtTitle  string
+	ID          string               // Format: "org.gtk.example"
+	Title       string               // Window title
+	Width       int                  // Window width
+	Height      int                  // Window height
+	Args        []string             // GTK command line arguments: https://www.systutorials.com/docs/linux/man/7-gtk-options/
+	Flags       gio.ApplicationFlags // See flags: https://pkg.go.dev/github.com/diamondburned/gotk4/pkg/gio/v2#ApplicationFlags
+	Headless    bool                 // Force without window
+	MultiWindow bool                 // Open a window for each widget
+	PackPolicy  PackPolicy           // Pack widgets found after the first window is opened
+	ErrorPolicy ErrorPolicy          // Handle errors of Actions in lists and maps
+
+	ShutdownTimeout time.Duration // Run waits for goroutines started with Go. Default: DefaultShutdownTimeout
+	ErrorDisplay    ErrorDisplay  // Present errors in window mode. Overridden by EnvErrorDisplay
+	RePanic         bool          // Don't recover panics in Actions, for debugging
+	DryRun          bool          // Run prints the Actions plan without creating the application
+	SortedMaps      bool          // Launch map entries sorted by names
+	WarningBar      bool          // Show warnings in an info bar at the top of Win
+	GuessName       bool          // Auto set ID and Title if empty
+	FmtID           string
+	FmtTitle        string
 
 	// Application callbacks (connected to application signals).
-	OnInit func(*gtk.Application) // Sets up the application when it first starts
-	OnRun  interface{}            // This corresponds to the application being launched by the desktop environment.
+	OnInit interface{} // Sets up the application when it first starts. An error aborts the startup.
+	OnRun  interface{} // This corresponds to the application being launched by the desktop environment.
 	OnStop func(*gtk.Application)
+	OnOpen func(files []gio.Filer, hint string) interface{} // Opens files. This corresponds to someone trying to open a document (or documents) using the application from the file browser, or similar.
 
-	// OnOpen        func(app *gtk.Application, files unsafe.Pointer, hint string, test string) // opens files and shows them in a new window. This corresponds to someone trying to open a document (or documents) using the application from the file browser, or similar.
+	OnCommandLine func(app *App, cmdline *gio.ApplicationCommandLine) int // Handles the command line in the primary instance. Returns the exit code, 0 activates the application, or opens the files arguments with OnOpen.
+	OnRemote      func(cmd *CommandLine) interface{}                      // Returns Actions for a command line forwarded by another launch of a unique application.
+	Logf          func(format string, args ...interface{})                // Prints the messages (errors, warnings, dry run plan, Println). Default: fmt.Printf with a new line.
 
 	// Pointers.
 	App *gtk.Application       // Set before OnNewApp
 	Win *gtk.ApplicationWindow // Set before OnNewWin. Only set if OnNewWin is defined.
 
 	// Private.
-	exitCode int // Go exit code.
+	exitCode    int                               // Go exit code.
+	err         error                             // Exec error returned by the application callbacks.
+	options     []option                          // Command line options declared with SetOption.
+	wins        map[string]*gtk.ApplicationWindow // Windows registry.
+	winCount    int                               // Number of windows registered, for default names.
+	packed      packed                            // Win packing state.
+	ctx         context.Context                   // Cancelled on Exit or shutdown.
+	cancel      context.CancelFunc
+	sources     map[externglib.SourceHandle]struct{} // Timeout sources removed on shutdown.
+	timeouts    []func()                             // Timeouts set before the application, added on startup.
+	routines    *routines                            // Goroutines started with Go, new for each Run.
+	result      Result                               // RunResult details.
+	runStart    time.Time                            // Run start time.
+	started     time.Time                            // End of the first activation.
+	aborted     bool                                 // OnInit failed, skip the activation.
+	stages      []Stage                              // Startup stages.
+	stagesUp    []Stage                              // Stages started, to stop at shutdown.
+	stopErrs    Errors                               // Stages stop errors.
+	middlewares []Middleware                         // Wrap every Action launched by Exec.
+	current     interface{}                          // Action launched.
+	provided    []reflect.Value                      // Values injected in Actions parameters.
+	entryPath   []string                             // Names of the map entries launched.
+	warnings    Warnings                             // Non-fatal problems collected.
+	hosted      chan int                             // Set when hosted by TestMainWindows, to signal the end.
+	hostEnd     bool                                 // The hosted App is ending.
+	named       bool                                 // GuessName applied by RunResult, from its caller.
+	exitAfter   time.Duration                        // Set by the --exit-after command line option.
 }
 
 //
@@ -295,24 +409,18 @@ func NewLarge(params ...Param) *App { return NewSized(1000, 800, params...) }
 // The window is created only for the first action that returned a valid widget.
 //
 // Locks the thread until application release when the last attached window is
-// closed or an exit is requested.
+// closed or an exit is requested. Then waits for the goroutines started with Go.
 //
-// Returns an error code.
+// Returns an error code. Errors are printed (see RunResult for details).
 func (app *App) Run(calls ...interface{}) int {
-	if app.OnRun != nil {
-		calls = append([]interface{}{app.OnRun}, calls...)
-	}
-	var e error
-	app.Init(func(_ *gtk.Application) { e = Exec(calls...)(app) })
-	exitGtk := app.App.Run(app.Args)
-	if e != nil {
-		fmt.Printf(FmtErrRun+"\n", e)
-		return 1
+	res := app.RunResult(calls...)
+	if res.Errors.IsError() {
+		app.logf(FmtErrRun, res.Errors)
 	}
-	if app.ExitCode() != 0 {
-		return app.ExitCode()
+	if len(res.Warnings) > 0 {
+		app.logf(FmtWarnRun, res.Warnings)
 	}
-	return exitGtk
+	return res.ExitCode
 }
 
 //
@@ -320,27 +428,96 @@ func (app *App) Run(calls ...interface{}) int {
 
 // Init creates the gtk.Application and connects its callbacks.
 func (app *App) Init(call func(app *gtk.Application)) {
-	if app.GuessName {
-		repo, packag := packageName()
-		if app.ID == "" {
-			app.ID = fmt.Sprintf(firstNonEmpty(app.FmtID, FmtID), repo, packag) // "gtkelp.appinfo"
-		}
-		if app.Title == "" {
-			app.FmtTitle = fmt.Sprintf(firstNonEmpty(app.FmtTitle, FmtTitle), repo, packag)
-		}
+	if app.GuessName && !app.named {
+		app.guessName(packageName())
+	}
+	if app.OnOpen != nil {
+		app.Flags |= gio.ApplicationHandlesOpen
+	}
+	if app.OnCommandLine != nil {
+		app.Flags |= gio.ApplicationHandlesCommandLine
 	}
+	if app.OnRemote != nil {
+		app.Flags |= gio.ApplicationHandlesCommandLine | gio.ApplicationSendEnvironment
+	}
+	app.initContext()
 	app.App = gtk.NewApplication(app.ID, app.Flags)
+	app.initOptions()
 
 	// Registered in their execution order to show how they are called.
 
+	app.App.Connect("startup", app.startup)
+
+	app.App.Connect("activate", call)
+
+	if app.OnOpen != nil {
+		app.App.Connect("open", app.open)
+	}
+
+	app.App.Connect("shutdown", app.shutdown)
+}
+
+// startup launches the OnInit Actions and the stages. An error aborts the
+// startup.
+func (app *App) startup(_ *gtk.Application) {
+	for _, add := range app.timeouts {
+		add()
+	}
+	app.timeouts = nil
 	if app.OnInit != nil {
-		app.App.Connect("startup", app.OnInit)
+		if e := Exec(app.OnInit)(app); e != nil {
+			app.abort(e)
+		}
+	}
+	if !app.aborted {
+		if e := app.initStages(); e != nil {
+			app.abort(e)
+		}
 	}
+	if !app.runStart.IsZero() {
+		app.result.Phases.Startup = time.Since(app.runStart)
+	}
+}
 
-	app.App.Connect("activate", call)
+// abort stores the error and closes the application without activation.
+func (app *App) abort(e error) {
+	app.err = e
+	app.aborted = true
+	app.quit()
+}
 
+// shutdown cancels the App context, removes the timeout sources, stops the
+// stages and calls OnStop. Then Go is rejected.
+func (app *App) shutdown(gtkapp *gtk.Application) {
+	if !app.started.IsZero() {
+		app.result.Phases.Running = time.Since(app.started)
+	}
+	defer app.timePhase(&app.result.Phases.Shutdown, time.Now())
+	app.cancelContext()
+	app.removeSources()
+	app.stopStages()
 	if app.OnStop != nil {
-		app.App.Connect("shutdown", app.OnStop)
+		app.OnStop(gtkapp)
+	}
+	app.closeRoutines()
+}
+
+// open converts the open signal files list and launches OnOpen Actions.
+// Replaces the activate callback when files are provided.
+func (app *App) open(_ *gtk.Application, files unsafe.Pointer, n int, hint string) {
+	if app.aborted {
+		return
+	}
+	defer app.timePhase(&app.result.Phases.Activate, time.Now())
+	list := make([]gio.Filer, n)
+	for i, ptr := range unsafe.Slice((*unsafe.Pointer)(files), n) {
+		list[i] = &gio.File{Object: externglib.Take(ptr)}
+	}
+	if calls := app.OnOpen(list, hint); calls != nil {
+		if e := Exec(calls)(app); e != nil {
+			app.keepError(e)
+			app.showError(e)
+		}
 	}
 }
 
@@ -357,164 +534,185 @@ func (app *App) NewWindow() *gtk.ApplicationWindow {
 }
 
 // Pack creates the widget and if it's usable, creates the window to pack it.
-func (app *App) Pack(call func() gtk.Widgetter) {
-	if app.Headless || app.Win != nil {
-		call() // Drop widget. TODO: or append under the first widget or in its own window ?
-		return
+func (app *App) Pack(call func() gtk.Widgetter) error {
+	return app.PackWindow(func() Window { return Window{Widget: call()} })
+}
+
+// PackWindow creates the widget and if it's usable, creates the window to pack
+// it with its settings. Without MultiWindow, only the first window is created
+// and the next widgets are packed with the PackPolicy.
+func (app *App) PackWindow(call func() Window) error {
+	switch {
+	case app.Headless:
+		call() // Drop widget.
+		return nil
+
+	case app.Win != nil && !app.MultiWindow && app.PackPolicy != PackNewWindow:
+		return app.packMore(call)
+	}
+	win := app.NewWindow()
+	first := app.Win == nil
+	if first {
+		app.Win = win // Set before the call to be usable by the Action.
 	}
-	app.Win = app.NewWindow()
+	defer func() { // Don't leave a hidden window to keep the app running.
+		if r := recover(); r != nil {
+			win.Destroy()
+			if first {
+				app.Win = nil
+			}
+			panic(r)
+		}
+	}()
 	w := call()
-	if w == nil {
+	if w.Widget == nil {
 		// TODO: handle error: widget nil
-		app.Win.Close()
-		app.Win = nil
-		return
+		win.Close()
+		if first {
+			app.Win = nil
+		}
+		return nil
+	}
+	if w.Title != "" {
+		win.SetTitle(w.Title)
 	}
-	app.Win.SetChild(w)
-	app.Win.Show()
+	if w.Width > 0 && w.Height > 0 {
+		win.SetDefaultSize(w.Width, w.Height)
+	}
+	app.register(w.Name, win)
+	if first {
+		app.packed = packed{first: w}
+		if app.WarningBar {
+			app.packWarningBar(win)
+		}
+		app.setContent(w.Widget)
+	} else {
+		win.SetChild(w.Widget)
+	}
+	win.Show()
+	app.result.WindowShown = true
+	return nil
 }
 
 //
 //----------------------------------------------------------[ LAUNCH ACTIONS ]--
 
 // Exec creates an Action that launch any kind of Actions.
+// Stops on the first error, unless ErrorPolicy is ErrorCollect.
 func Exec(calls ...interface{}) func(*App) error {
-	return func(app *App) error {
-		var w gtk.Widgetter
-		var e error
-		for _, uncast := range calls {
-			switch call := uncast.(type) {
-
-			//
-			// Widgets.
-
-			case func() gtk.Widgetter:
-				app.Pack(func() gtk.Widgetter { return call() })
-
-			case func(app *App) gtk.Widgetter:
-				app.Pack(func() gtk.Widgetter { return call(app) })
-
-			case func() (gtk.Widgetter, error):
-				app.Pack(func() gtk.Widgetter {
-					w, e = call()
-					if e != nil {
-						return nil
-					}
-					return w
-				})
-
-			case func(app *App) (gtk.Widgetter, error):
-				app.Pack(func() gtk.Widgetter {
-					w, e = call(app)
-					if e != nil {
-						return nil
-					}
-					return w
-				})
-
-				// useful ???
-			case chan gtk.Widgetter:
-				app.Pack(func() gtk.Widgetter { w := <-call; close(call); return w }) // <3
-
-				//
-				// Errors: errors lists
-
-			case func(app *App) (gtk.Widgetter, Errors):
-				var errs Errors
-				app.Pack(func() gtk.Widgetter {
-					w, errs := call(app)
-					if errs.IsError() {
-						return nil
-					}
-					return w
-				})
-				if errs.IsError() {
-					return errs.ToError()
-				}
-
-				//
-				// Headless.
-
-			case func():
-				call()
-
-			case func() error:
-				return call()
-
-			case Param:
-				call(app)
-
-			case func(app *App):
-				call(app)
-
-			case func(app *App) error:
-				return call(app)
-
-			case func() func(*App):
-				call()(app)
-
-				//
-				// Lists.
-
-			case []interface{}: // Recursion to allow any kind of crazy config.
-				e = Exec(call...)(app)
-
-			case map[string]interface{}: // Recursion... Déjà vu.
-				var errs Errors
-				for name, recall := range call { // Warning, from a map, the order is random
-					e := Exec(recall)(app) // This is mostly for tests and serial queuing.
-					if e != nil {
-						errs.Append(fmt.Errorf(FmtErrExec, name, e))
-					}
-				}
-				if errs.IsError() {
-					e = errs.ToError()
-				}
-
-				//
-				// String as label could be used for tests.
-
-			case string:
-				app.Pack(func() gtk.Widgetter { return gtk.NewLabel(call) })
-
-			case func() string:
-				app.Pack(func() gtk.Widgetter { return gtk.NewLabel(call()) })
-
-			case func(app *App) string:
-				app.Pack(func() gtk.Widgetter { return gtk.NewLabel(call(app)) })
-
-				//
-				// Errors
-
-			case error:
-				return call
-
-			case Errors:
-				if call.IsError() {
-					return call.ToError()
-				}
-
-			default:
-				return fmt.Errorf(FmtErrTypeUnknown, call)
-			}
+	return func(app *App) error { return app.execList(calls, nil) }
+}
 
-			if e != nil {
-				return e
-			}
+// execList launches the Actions like Exec. With ErrorCollect, errors are
+// annotated with their path: paths[i], or [i] without paths.
+func (app *App) execList(calls []interface{}, paths []string) error {
+	var errs Errors
+	for i, call := range calls {
+		e := app.exec(call)
+		if e == nil {
+			continue
 		}
-		return nil
+		app.setFailed(call)
+		switch {
+		case app.ErrorPolicy != ErrorCollect:
+			return e
+
+		case len(calls) == 1: // No need to annotate.
+			errs.wrapErrors(e, "%w")
+
+		case paths != nil:
+			errs.wrapErrors(e, FmtErrExecPath, paths[i], ActionName(call))
+
+		default:
+			errs.wrapErrors(e, FmtErrExecPath, fmt.Sprintf("[%d]", i), ActionName(call))
+		}
+	}
+	if errs.IsError() {
+		return errs
 	}
+	return nil
+}
+
+// exec launches one Action through the middlewares. Lists are not wrapped, as
+// their Actions are.
+func (app *App) exec(call interface{}) error {
+	switch call.(type) {
+	case []interface{}, map[string]interface{}, OrderedActions:
+		return app.dispatch(call)
+	}
+	next := ActionFunc(func(app *App) error { return app.keepWarnings(app.dispatch(call)) })
+	if !app.RePanic {
+		next = recoverPanic(next)
+	}
+	for i := len(app.middlewares) - 1; i >= 0; i-- {
+		next = app.middlewares[i](next)
+	}
+	current := app.current
+	app.current = call
+	defer func() { app.current = current }()
+	return next(app)
 }
 
 //
 //--------------------------------------------------------------------[ EXIT ]--
 
 // Exit closes the application and terminates Run. Stores the go exit code.
-func (app *App) Exit(exitCode int) { app.exitCode = exitCode; app.App.Quit() }
+// Cancels the App context.
+func (app *App) Exit(exitCode int) {
+	app.exitCode = exitCode
+	app.cancelContext()
+	app.quit()
+}
 
 // ExitCode returns the go exit code provided by any of the Exit method.
 func (app *App) ExitCode() int { return app.exitCode }
 
+// Context returns the App context, cancelled when the application is closing
+// (Exit, ExitAfter or shutdown).
+func (app *App) Context() context.Context {
+	if app.ctx == nil {
+		app.ctx, app.cancel = context.WithCancel(context.Background())
+	}
+	return app.ctx
+}
+
+// initContext creates the App context if it's missing or cancelled (new Run).
+func (app *App) initContext() {
+	if app.ctx == nil || app.ctx.Err() != nil {
+		app.ctx, app.cancel = context.WithCancel(context.Background())
+	}
+}
+
+// cancelContext cancels the App context if it was created.
+func (app *App) cancelContext() {
+	if app.cancel != nil {
+		app.cancel()
+	}
+}
+
+// ExitError defines an error with the exit code to return from Run.
+// Found anywhere in the errors chain of Run, the first one sets the exit code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// ExitWith returns an ExitError. Usable as an Action to stop Exec.
+func ExitWith(exitCode int, err error) error {
+	return &ExitError{Code: exitCode, Err: err}
+}
+
+// Error returns the wrapped error message.
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf(FmtErrExitCode, e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *ExitError) Unwrap() error { return e.Err }
+
 //
 //-----------------------------------------------------------------[ ACTIONS ]--
 
@@ -524,15 +722,53 @@ func Exit(exitCode int) Action {
 }
 
 // ExitAfter creates a Param that closes the application after duration.
+// The exit is launched on the GTK main loop (see After), the duration starts
+// on startup when set before Run.
 // Usable at any moment.
 func ExitAfter(d time.Duration, exitCode int) Param {
-	return func(app *App) {
-		go time.AfterFunc(d, func() { app.Exit(exitCode) })
+	return func(app *App) { app.timeout(d, false, Exit(exitCode)) }
+}
+
+// InNewWindow creates an Action that launches Actions with a new window for
+// the first valid widget. The previous Win is restored when it was set.
+// Usable at any moment.
+func InNewWindow(calls ...interface{}) Action {
+	return func(app *App) error {
+		win, packed := app.Win, app.packed
+		app.Win = nil
+		e := Exec(calls...)(app)
+		if win != nil {
+			app.Win, app.packed = win, packed
+		}
+		return e
 	}
 }
 
-// Println creates an Action that prints data, usable in tests.
-func Println(args ...interface{}) func() { return func() { fmt.Println(args...) } }
+// OpenEach creates an OnOpen callback that launches the Actions returned for
+// each file, with a new window for each of them.
+func OpenEach(call func(file gio.Filer, hint string) interface{}) func([]gio.Filer, string) interface{} {
+	return func(files []gio.Filer, hint string) interface{} {
+		list := make([]interface{}, len(files))
+		for i, file := range files {
+			list[i] = InNewWindow(call(file, hint))
+		}
+		return list
+	}
+}
+
+// Println creates an Action that prints data with Logf, usable in tests.
+func Println(args ...interface{}) func(*App) {
+	return func(app *App) { app.logf("%s", strings.TrimSuffix(fmt.Sprintln(args...), "\n")) }
+}
+
+// logf prints the message with Logf, or fmt.Printf with a new line.
+func (app *App) logf(format string, args ...interface{}) {
+	if app.Logf != nil {
+		app.Logf(format, args...)
+		return
+	}
+	fmt.Printf(format+"\n", args...)
+}
 
 //
 //--------------------------------------------------------------[ SET PARAMS ]--
@@ -559,6 +795,19 @@ func SetHeadless() Param {
 	return func(app *App) { app.Headless = true }
 }
 
+// SetMultiWindow creates a Param that opens a window for each widget.
+// Usable at any moment.
+func SetMultiWindow() Param {
+	return func(app *App) { app.MultiWindow = true }
+}
+
+// SetPackPolicy creates a Param that sets how to pack widgets found after the
+// first window is opened.
+// Usable at any moment.
+func SetPackPolicy(policy PackPolicy) Param {
+	return func(app *App) { app.PackPolicy = policy }
+}
+
 // SetSize creates a Param that sets the window title.
 // Usable until Win is opened.
 func SetSize(w, h int) Param {
@@ -595,9 +844,9 @@ func SetGuessName() Param {
 //
 //-----------------------------------------[ PARAMS - Only usable before Run ]--
 
-// SetOnInit creates a Param that sets the OnInit Action.
+// SetOnInit creates a Param that sets the OnInit Actions.
 // Only usable before Run.
-func SetOnInit(call func(*gtk.Application)) Param {
+func SetOnInit(call interface{}) Param {
 	return func(app *App) { app.OnInit = call }
 }
 
@@ -610,6 +859,13 @@ func SetOnRun(call ...interface{}) Param {
 	return func(app *App) { app.OnRun = call }
 }
 
+// SetOnOpen creates a Param that sets the OnOpen callback.
+// Activates the gio.ApplicationHandlesOpen flag.
+// Only usable before Run.
+func SetOnOpen(call func(files []gio.Filer, hint string) interface{}) Param {
+	return func(app *App) { app.OnOpen = call }
+}
+
 // SetOnStop creates a Param that sets the OnStop Action.
 // Only usable before Run.
 func SetOnStop(call func(*gtk.Application)) Param {
@@ -648,9 +904,15 @@ func SetFlagNonUnique() Param {
 // Errors define an error list. They can be generated by the buildhelp package.
 type Errors []error
 
-// IsError returns true is the error list is not empty.
+// IsError returns true is the error list is not empty. Warnings entries are
+// not errors.
 func (e Errors) IsError() bool {
-	return len(e) > 0
+	for _, err := range e {
+		if _, ok := err.(Warnings); !ok {
+			return true
+		}
+	}
+	return false
 }
 
 // Append adds an error to the list.
@@ -659,7 +921,8 @@ func (e *Errors) Append(more ...error) {
 }
 
 // ToError converts the error list to a single go error.
-func (e Errors) ToError() error { return errors.New(e.Error()) }
+// The list is kept and can be tested with errors.Is and errors.As.
+func (e Errors) ToError() error { return e }
 
 // Error returns the list of errors as string. Acts as an error for fmt.
 func (e Errors) Error() string {
@@ -673,16 +936,84 @@ func (e Errors) Error() string {
 	return strings.Join(list, "\n")
 }
 
-// Widget returns either a new error label widget or the provided widget.
+// Unwrap returns the list of errors.
+func (e Errors) Unwrap() []error { return e }
+
+// Is returns true if one of the errors matches target (errors.Is).
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error that matches target (errors.As).
+func (e Errors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the errors accepted by the test.
+func (e Errors) Filter(test func(error) bool) Errors {
+	var list Errors
+	for _, err := range e {
+		if test(err) {
+			list = append(list, err)
+		}
+	}
+	return list
+}
+
+// Dedup returns the errors without duplicated messages, in the first seen order.
+func (e Errors) Dedup() Errors {
+	var list Errors
+	seen := make(map[string]bool, len(e))
+	for _, err := range e {
+		if !seen[err.Error()] {
+			seen[err.Error()] = true
+			list = append(list, err)
+		}
+	}
+	return list
+}
+
+// CountByType returns the number of errors for each type, like
+// "*fs.PathError". Annotations from fmt.Errorf (FmtErrExec...) are skipped.
+func (e Errors) CountByType() map[string]int {
+	count := make(map[string]int)
+	for _, err := range e {
+		for reflect.TypeOf(err) == typeWrapError {
+			err = errors.Unwrap(err)
+		}
+		count[fmt.Sprintf("%T", err)]++
+	}
+	return count
+}
+
+// typeWrapError is the type of errors annotated with fmt.Errorf.
+var typeWrapError = reflect.TypeOf(fmt.Errorf("%w", errors.New("")))
+
+// Widget returns either a new errors list widget or the provided widget.
 // If a widget is provided as optional parameter, it will be returned when no
-// error is found to ensure a valid widget is returned.
+// error is found (warnings only) to ensure a valid widget is returned.
+// The list is scrollable with selectable texts, icons for errors and warnings,
+// locations of LocatedError, and a copy all button.
 func (e Errors) Widget(b ...gtk.Widgetter) gtk.Widgetter {
 	switch {
 	case e.IsError():
-		return gtk.NewLabel(fmt.Sprintf(FmtErrLabel, e))
+		return e.widget()
 
 	case len(b) > 0 && b[0] != nil:
 		return b[0]
+
+	case len(e) > 0: // Warnings.
+		return e.widget()
 	}
 	return gtk.NewLabel(TxtErrNoWidget)
 }
@@ -690,16 +1021,36 @@ func (e Errors) Widget(b ...gtk.Widgetter) gtk.Widgetter {
 //
 //-------------------------------------------------------------[ FORMAT NAME ]--
 
-// packageName tries to find the repository and package name from the caller.
-func packageName() (repo, packag string) {
-	_, path, _, _ := runtime.Caller(3)                // path= .../gtk4/gtkelp/gtkest/gtkest.go
-	repo, packag = filepath.Split(filepath.Dir(path)) // Drop filename and get package name
+// guessName sets the missing ID and title from the repository and package names.
+func (app *App) guessName(repo, packag string) {
+	if app.ID == "" {
+		app.ID = fmt.Sprintf(firstNonEmpty(app.FmtID, FmtID), repo, packag) // "gtkelp.appinfo"
+	}
+	if app.Title == "" {
+		app.FmtTitle = fmt.Sprintf(firstNonEmpty(app.FmtTitle, FmtTitle), repo, packag)
+	}
+}
 
-	if repo == "gtkelp" && packag == "gtkest" { // Coming from test package, retry one call further.
-		_, path, _, _ := runtime.Caller(4)
-		repo, packag = filepath.Split(filepath.Dir(path))
+// grunPackage is the import path of this package, skipped to find the caller.
+var grunPackage = reflect.TypeOf(App{}).PkgPath()
+
+// packageName tries to find the repository and package name from the first
+// caller outside this package (and the gtkest test package).
+func packageName() (repo, packag string) {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		repo, packag = filepath.Split(filepath.Dir(frame.File)) // Drop filename and get package name
+		switch {
+		case strings.HasPrefix(frame.Function, grunPackage+"."),
+			filepath.Base(repo) == "gtkelp" && packag == "gtkest": // Coming from test package, retry one call further.
+			if more {
+				continue
+			}
+		}
+		return filepath.Base(repo), packag // Trim all the path from the repo name
 	}
-	return filepath.Base(repo), packag // Trim all the path from the repo name
 }
 
 // firstNonEmpty returns the first non empty string found.
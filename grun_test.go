@@ -1,12 +1,26 @@
 package grun_test
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/gio/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
 
 	"github.com/gtkool4/grun"
 )
 
+func TestMain(m *testing.M) { grun.TestMain(m) }
+
 func Test_errorPaths(t *testing.T) {
 	var errs grun.Errors
 	if errs.IsError() || errs.Error() != "" {
@@ -31,3 +45,731 @@ func Test_newApps(t *testing.T) {
 		}
 	}
 }
+
+func Test_onOpenFlag(t *testing.T) {
+	app := grun.New(grun.SetOnOpen(func([]gio.Filer, string) interface{} { return nil }))
+	app.Init(func(*gtk.Application) {})
+	if app.Flags&gio.ApplicationHandlesOpen == 0 {
+		t.Error("OnOpen must set the HandlesOpen flag")
+	}
+}
+
+func Test_commandLineOpen(t *testing.T) {
+	var opened []string
+	code := grun.New(
+		grun.SetFlagNonUnique(),
+		grun.SetArgs("test", "file.txt"),
+		grun.SetOnCommandLine(func(*grun.App, *gio.ApplicationCommandLine) int { return 0 }),
+		grun.SetOnOpen(func(files []gio.Filer, _ string) interface{} {
+			for _, file := range files {
+				opened = append(opened, file.Basename())
+			}
+			return grun.Exit(0)
+		}),
+	).Run()
+	if code != 0 || fmt.Sprint(opened) != "[file.txt]" {
+		t.Errorf("files arguments must be opened: code=%d %v", code, opened)
+	}
+}
+
+func Test_openKeepsError(t *testing.T) {
+	app := grun.New(
+		grun.SetFlagNonUnique(),
+		grun.SetErrorDisplay(grun.DisplayPanel), // Keeps running.
+		grun.SetOnOpen(func([]gio.Filer, string) interface{} { return func() {} }),
+	)
+	res := app.RunResult(
+		grun.After(20*time.Millisecond, func(app *grun.App) {
+			app.App.Open([]gio.Filer{gio.NewFileForPath("file.txt")}, "")
+		}),
+		grun.After(50*time.Millisecond, grun.Exit(0)),
+		errors.New("fail"),
+	)
+	if res.ExitCode != 1 || len(res.Errors) != 1 {
+		t.Errorf("a successful open must keep the first error: %+v", res)
+	}
+}
+
+func Test_options(t *testing.T) {
+	var (
+		verbose bool
+		name    string
+		count   int
+		delay   time.Duration
+		tags    []string
+	)
+	app := grun.New(
+		grun.SetFlagNonUnique(),
+		grun.SetArgs("prog", "--verbose", "--name=grun", "--count=3", "--delay=2s", "--tag=a", "--tag=b",
+			"--headless", "--exit-after=10ms"),
+		grun.SetOption("verbose", 'v', "", &verbose),
+		grun.SetOption("name", 0, "", &name),
+		grun.SetOption("count", 0, "", &count),
+		grun.SetOption("delay", 0, "", &delay),
+		grun.SetOption("tag", 0, "", &tags),
+		grun.SetOptionHeadless(),
+		grun.SetOptionExitAfter(),
+	)
+	res := app.RunResult(func(app *grun.App) { app.App.Hold() }) // Closed by exit-after.
+	if res.ExitCode != 0 || !app.Headless {
+		t.Errorf("bad options run: headless=%t %+v", app.Headless, res)
+	}
+	if !verbose || name != "grun" || count != 3 || delay != 2*time.Second || fmt.Sprint(tags) != "[a b]" {
+		t.Errorf("bad options targets: %t %q %d %s %v", verbose, name, count, delay, tags)
+	}
+}
+
+func Test_optionTypeUnknown(t *testing.T) {
+	var f float64
+	app := grun.New(
+		grun.SetFlagNonUnique(),
+		grun.SetOption("unknown", 0, "", &f),
+	)
+	if app.Run(grun.Exit(0)) != 1 {
+		t.Error("unknown option target type must fail")
+	}
+}
+
+func Test_commandLineGetenv(t *testing.T) {
+	cmd := grun.CommandLine{Environ: []string{"HOME=/home/user", "HOMER=simpson", "EMPTY="}}
+	switch {
+	case cmd.Getenv("HOME") != "/home/user",
+		cmd.Getenv("HOMER") != "simpson",
+		cmd.Getenv("EMPTY") != "",
+		cmd.Getenv("MISSING") != "":
+		t.Errorf("Getenv failed on %v", cmd.Environ)
+	}
+}
+
+// Test_remote runs a primary instance and two remote launches on a private
+// session bus, with Test_remoteHelper. Remote options don't change the primary.
+func Test_remote(t *testing.T) {
+	if _, e := exec.LookPath("dbus-daemon"); e != nil {
+		t.Skip("dbus-daemon not found")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	bus := exec.CommandContext(ctx, "dbus-daemon", "--session", "--nofork", "--print-address")
+	busOut, _ := bus.StdoutPipe()
+	if e := bus.Start(); e != nil {
+		t.Fatal(e)
+	}
+	defer bus.Process.Kill()
+	address, e := bufio.NewReader(busOut).ReadString('\n')
+	if e != nil {
+		t.Fatal(e)
+	}
+	launch := func(role, args string) *exec.Cmd {
+		cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=^Test_remoteHelper$")
+		cmd.Env = append(os.Environ(),
+			"DBUS_SESSION_BUS_ADDRESS="+strings.TrimSpace(address),
+			"GRUN_TEST_REMOTE="+role,
+			"GRUN_TEST_ARGS="+args)
+		return cmd
+	}
+
+	primary := launch("primary", "")
+	primaryOut, _ := primary.StdoutPipe()
+	if e := primary.Start(); e != nil {
+		t.Fatal(e)
+	}
+	lines := bufio.NewScanner(primaryOut)
+	for lines.Scan() && lines.Text() != "ready" { // Skip the test output.
+	}
+	if lines.Text() != "ready" {
+		t.Fatal("primary instance not ready")
+	}
+
+	for _, remote := range []struct {
+		args string
+		code int
+	}{{"file.txt", 0}, {"quit --headless", 3}} {
+		e := launch("secondary", remote.args).Run()
+		var exitErr *exec.ExitError
+		switch {
+		case remote.code == 0 && e != nil,
+			remote.code != 0 && (!errors.As(e, &exitErr) || exitErr.ExitCode() != remote.code):
+			t.Errorf("remote %q: bad exit, want code %d: %v", remote.args, remote.code, e)
+		}
+	}
+
+	var got []string
+	for lines.Scan() {
+		if strings.HasPrefix(lines.Text(), "remote:") || strings.HasPrefix(lines.Text(), "open:") {
+			got = append(got, lines.Text())
+		}
+	}
+	if e := primary.Wait(); e != nil {
+		t.Errorf("primary instance failed: %v", e)
+	}
+	if strings.Join(got, "|") != "remote: file.txt headless=false/false|open: file.txt|remote: quit headless=true/false" {
+		t.Errorf("bad primary output: %q", got)
+	}
+}
+
+// Test_remoteHelper is the application launched by Test_remote.
+func Test_remoteHelper(t *testing.T) {
+	if os.Getenv("GRUN_TEST_REMOTE") == "" {
+		t.Skip("launched by Test_remote")
+	}
+	args := append([]string{"grun-remote"}, strings.Fields(os.Getenv("GRUN_TEST_ARGS"))...)
+	app := grun.New(
+		grun.SetID("com.github.gtkool4.grun.remote"),
+		grun.SetArgs(args...),
+		grun.SetOptionHeadless(),
+		grun.SetOnOpen(func(files []gio.Filer, _ string) interface{} {
+			for _, file := range files {
+				fmt.Println("open:", file.Basename())
+			}
+			return nil
+		}),
+	)
+	app.OnRemote = func(cmd *grun.CommandLine) interface{} {
+		fmt.Printf("remote: %s headless=%t/%t\n", cmd.Args[1], cmd.Options.Contains("headless"), app.Headless)
+		if cmd.Args[1] == "quit" {
+			cmd.ExitCode = 3
+			return grun.After(100*time.Millisecond, grun.Exit(0)) // After the reply.
+		}
+		return nil
+	}
+	res := app.RunResult(func(app *grun.App) {
+		app.App.Hold() // Primary only, wait for the remote launches.
+		fmt.Println("ready")
+	})
+	os.Exit(res.ExitCode)
+}
+
+func Test_windowsRegistry(t *testing.T) {
+	app := grun.New(grun.SetMultiWindow())
+	if !app.MultiWindow || app.Window("win1") != nil || len(app.Windows()) != 0 {
+		t.Error("registry must be empty before Run")
+	}
+}
+
+func Test_multiWindow(t *testing.T) {
+	window := func(name, title string) func() grun.Window {
+		return func() grun.Window { return grun.Window{Name: name, Title: title, Widget: gtk.NewLabel(name)} }
+	}
+	res := grun.New(grun.SetFlagNonUnique(), grun.SetMultiWindow()).RunResult(
+		window("main", "Main"),
+		window("second", "Second"),
+		func(app *grun.App) error {
+			switch {
+			case len(app.Windows()) != 2:
+				return fmt.Errorf("bad windows count: %v", app.Windows())
+
+			case app.Window("main") == nil || app.Window("main").Title() != "Main",
+				app.Window("second") == nil || app.Window("second").Title() != "Second":
+				return errors.New("windows not registered by name")
+			}
+			return nil
+		},
+		grun.Exit(0),
+	)
+	if res.ExitCode != 0 || !res.WindowShown {
+		t.Errorf("bad multi window run: %+v", res)
+	}
+}
+
+func Test_packPolicyError(t *testing.T) {
+	app := grun.New(grun.SetFlagNonUnique(), grun.SetPackPolicy(grun.PackError))
+	var launched bool
+	second := func() gtk.Widgetter { launched = true; return gtk.NewLabel("second") }
+	if app.Run(grun.Exit(0), "first", second) != 1 || !launched {
+		t.Error("PackError policy must launch and fail on the second widget")
+	}
+}
+
+// children returns the texts of the labels in the container.
+func children(w gtk.Widgetter) (texts []string) {
+	for child := w.FirstChild(); child != nil; child = child.NextSibling() {
+		if label, ok := child.(*gtk.Label); ok {
+			texts = append(texts, label.Text())
+		}
+	}
+	return texts
+}
+
+func Test_packPolicies(t *testing.T) {
+	for _, test := range []struct {
+		policy grun.PackPolicy
+		check  func(content gtk.Widgetter) string
+		want   string
+	}{
+		{grun.PackBox, func(box gtk.Widgetter) string { return fmt.Sprint(children(box)) }, "[first second third]"},
+		{grun.PackStack, func(box gtk.Widgetter) string {
+			stack := box.FirstChild().NextSibling().(*gtk.Stack) // After the switcher.
+			return fmt.Sprint(children(stack), stack.ChildByName("page3") != nil)
+		}, "[first second third] true"},
+		{grun.PackNotebook, func(notebook gtk.Widgetter) string {
+			return fmt.Sprint(notebook.(*gtk.Notebook).NPages(), notebook.(*gtk.Notebook).NthPage(0).(*gtk.Label).Text())
+		}, "3 first"},
+	} {
+		var got string
+		grun.New(grun.SetFlagNonUnique(), grun.SetPackPolicy(test.policy)).Run(
+			"first", "second", "third",
+			func(app *grun.App) { got = test.check(app.Win.Child()) },
+			grun.Exit(0),
+		)
+		if got != test.want {
+			t.Errorf("bad packing with policy %d: %q, want %q", test.policy, got, test.want)
+		}
+	}
+}
+
+func Test_contextCancel(t *testing.T) {
+	var ctx context.Context
+	grun.New(grun.SetFlagNonUnique()).Run(
+		func(c context.Context) { ctx = c },
+		grun.Exit(0),
+	)
+	if ctx == nil || ctx.Err() == nil {
+		t.Error("context must be cancelled after Run")
+	}
+}
+
+func Test_scheduleEvery(t *testing.T) {
+	count := 0
+	code := grun.New(grun.SetFlagNonUnique()).Run(
+		func(app *grun.App) { app.App.Hold() }, // Keep running without window.
+		grun.Every(time.Millisecond, func() error {
+			count++
+			if count == 3 {
+				return errors.New("stop")
+			}
+			return nil
+		}),
+		grun.After(time.Second, grun.Exit(2)), // Removed on shutdown.
+	)
+	if code != 1 || count != 3 {
+		t.Errorf("Every stopped after %d calls with code %d", count, code)
+	}
+}
+
+func Test_invoke(t *testing.T) {
+	var got string
+	res := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless()).RunResult(func(app *grun.App) {
+		app.App.Hold() // Keep running without window.
+		go app.Invoke(func(app *grun.App) { got = "on the loop"; app.Exit(0) })
+	})
+	if res.ExitCode != 0 || got != "on the loop" {
+		t.Errorf("Invoke must launch the Action on the loop: %q %+v", got, res)
+	}
+
+	res = grun.New(grun.SetFlagNonUnique(), grun.SetHeadless(), grun.ExitAfter(10*time.Millisecond, 3)).RunResult(
+		func(app *grun.App) { app.App.Hold() },
+	)
+	if res.ExitCode != 3 {
+		t.Errorf("ExitAfter set before Run must exit: %+v", res)
+	}
+}
+
+func Test_goRoutines(t *testing.T) {
+	errGo := errors.New("routine")
+	code := grun.New(grun.SetFlagNonUnique(), grun.SetShutdownTimeout(time.Second)).Run(
+		func(app *grun.App) {
+			app.GoHold(func(ctx context.Context) error { return errGo })
+			app.Go(func(ctx context.Context) error { <-ctx.Done(); return nil })
+		},
+	)
+	if code != 1 {
+		t.Errorf("goroutine error must be returned, got code %d", code)
+	}
+}
+
+func Test_goShutdownTimeout(t *testing.T) {
+	app := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless(), grun.SetShutdownTimeout(10*time.Millisecond))
+	res := app.RunResult(func(app *grun.App) {
+		app.Go(func(context.Context) error { time.Sleep(200 * time.Millisecond); return nil }) // Ignores ctx.
+	})
+	if res.ExitCode != 1 || len(res.Errors) != 1 {
+		t.Errorf("shutdown timeout must fail: %+v", res)
+	}
+
+	started := make(chan struct{})
+	app.Go(func(context.Context) error { close(started); return nil })
+	select {
+	case <-started:
+		t.Error("Go must be rejected after the shutdown")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	res = app.RunResult(func(app *grun.App) { app.Go(func(context.Context) error { return nil }) })
+	if res.ExitCode != 0 || len(res.Errors) != 0 {
+		t.Errorf("next run must not wait for the previous goroutines: %+v", res)
+	}
+}
+
+func failingAction() error { return errors.New("fail") }
+
+func Test_runResult(t *testing.T) {
+	res := grun.New(grun.SetFlagNonUnique()).RunResult(
+		func() {},
+		[]interface{}{failingAction},
+	)
+	switch {
+	case res.ExitCode != 1, len(res.Errors) != 1, res.WindowShown:
+		t.Errorf("bad result: %+v", res)
+
+	case res.Failed != "github.com/gtkool4/grun_test.failingAction":
+		t.Errorf("bad failed Action name: %s", res.Failed)
+	}
+}
+
+func Test_onInitError(t *testing.T) {
+	var run, stop bool
+	code := grun.New(
+		grun.SetFlagNonUnique(),
+		grun.SetOnInit(func(*grun.App) error { return errors.New("init") }),
+		grun.SetOnRun(func() { run = true }),
+		grun.SetOnStop(func(*gtk.Application) { stop = true }),
+	).Run(grun.Exit(0))
+	if code != 1 || run || !stop {
+		t.Errorf("init error: code=%d run=%t stop=%t", code, run, stop)
+	}
+}
+
+func Test_stages(t *testing.T) {
+	var list []string
+	step := func(name string, e error) func() error {
+		return func() error { list = append(list, name); return e }
+	}
+	code := grun.New(
+		grun.SetFlagNonUnique(),
+		grun.SetStages(
+			grun.Stage{Name: "db", After: []string{"config"}, Init: step("db", nil), Stop: step("-db", nil)},
+			grun.Stage{Name: "config", Init: step("config", nil), Stop: step("-config", nil)},
+			grun.Stage{Name: "plugins", After: []string{"db"}, Init: step("plugins", errors.New("fail")), Stop: step("-plugins", nil)},
+		),
+	).Run(grun.Exit(0))
+
+	if code != 1 || fmt.Sprint(list) != "[config db plugins -db -config]" {
+		t.Errorf("bad stages: code=%d %v", code, list)
+	}
+}
+
+func Test_middleware(t *testing.T) {
+	var names []string
+	logger := func(next grun.ActionFunc) grun.ActionFunc {
+		return func(app *grun.App) error {
+			names = append(names, fmt.Sprintf("%T", app.CurrentAction()))
+			return next(app)
+		}
+	}
+	grun.New(grun.SetFlagNonUnique(), grun.SetUse(logger)).Run(
+		func() {},
+		[]interface{}{func() error { return nil }, map[string]interface{}{"exit": grun.Exit(0)}},
+	)
+	if fmt.Sprint(names) != "[func() func() error func(*grun.App)]" {
+		t.Errorf("bad middleware calls: %v", names)
+	}
+}
+
+func panicAction() { panic("boom") }
+
+func Test_panicRecovered(t *testing.T) {
+	res := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless()).RunResult(grun.Exit(0), panicAction)
+	var pe *grun.PanicError
+	if res.ExitCode != 1 || len(res.Errors) != 1 || !errors.As(res.Errors[0], &pe) {
+		t.Fatalf("panic not recovered: %+v", res)
+	}
+	if pe.Action != "github.com/gtkool4/grun_test.panicAction" || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Errorf("bad panic error: %s", pe)
+	}
+}
+
+func Test_panicWindow(t *testing.T) {
+	panicWidget := func() gtk.Widgetter { panic("boom") }
+	res := grun.New(grun.SetFlagNonUnique()).RunResult(panicWidget)
+	if res.ExitCode != 1 || res.WindowShown {
+		t.Errorf("widget panic must close the window and quit: %+v", res)
+	}
+
+	app := grun.New(grun.SetFlagNonUnique(), grun.SetErrorDisplay(grun.DisplayPanel))
+	res = app.RunResult(grun.After(50*time.Millisecond, grun.Exit(0)), panicWidget)
+	if res.ExitCode != 1 || !res.WindowShown {
+		t.Errorf("widget panic must be shown in the panel: %+v", res)
+	}
+}
+
+type counter int
+
+// named is only implemented by builder, its adapter stays registered.
+type named interface{ builderName() string }
+
+type builder struct{ name string }
+
+func (b builder) builderName() string { return b.name }
+
+func Test_registerActionType(t *testing.T) {
+	var got []string
+	grun.RegisterActionType(counter(0), func(call interface{}) grun.ActionFunc {
+		return func(*grun.App) error { got = append(got, fmt.Sprint("counter", call.(counter))); return nil }
+	})
+	grun.RegisterActionType((*named)(nil), func(call interface{}) grun.ActionFunc {
+		return func(*grun.App) error { got = append(got, call.(named).builderName()); return nil }
+	})
+
+	res := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless()).RunResult(grun.Exit(0), counter(2), builder{"ui"})
+	if res.ExitCode != 0 || fmt.Sprint(got) != "[counter2 ui]" {
+		t.Errorf("registered types not launched: %v %+v", got, res)
+	}
+
+	defer func() {
+		if r := recover(); r != grun.TxtErrRegisterNil {
+			t.Errorf("untyped nil sample must panic with a clear message: %v", r)
+		}
+	}()
+	grun.RegisterActionType(nil, nil)
+}
+
+type config struct{ name string }
+
+func Test_provide(t *testing.T) {
+	var got string
+	app := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless(), grun.SetProvide(&config{"cfg"}, errors.New("dep")))
+	res := app.RunResult(grun.Exit(0), func(a *grun.App, gtkapp *gtk.Application, cfg *config, dep error) error {
+		if a != app || gtkapp != app.App {
+			return errors.New("bad app injected")
+		}
+		got = cfg.name + " " + dep.Error()
+		return nil
+	})
+	if res.ExitCode != 0 || got != "cfg dep" {
+		t.Errorf("values not injected: %q %+v", got, res)
+	}
+
+	res = grun.New(grun.SetFlagNonUnique(), grun.SetHeadless()).RunResult(grun.Exit(0), func(*config) {})
+	if res.ExitCode != 1 || len(res.Errors) != 1 {
+		t.Fatalf("unresolved parameter not returned: %+v", res)
+	}
+	if want := "*grun_test.config"; !strings.Contains(res.Errors[0].Error(), want) {
+		t.Errorf("error should name %s: %s", want, res.Errors[0])
+	}
+}
+
+func Test_validate(t *testing.T) {
+	errs := grun.Validate(func() {}, 42, map[string]interface{}{"load": []interface{}{func() {}, 3.14}})
+	if len(errs) != 2 ||
+		!strings.Contains(errs[0].Error(), "[1]") ||
+		!strings.Contains(errs[1].Error(), `[2].map["load"][1]`) {
+		t.Fatalf("bad validation errors: %v", errs)
+	}
+
+	var launched bool
+	res := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless()).RunResult(func() { launched = true }, 42)
+	if launched || res.ExitCode != 1 || len(res.Errors) != 1 {
+		t.Errorf("invalid Actions must stop Run before launch: %+v", res)
+	}
+	res = grun.New(grun.SetFlagNonUnique(), grun.SetHeadless()).RunResult(func() { launched = true }, func(*config) {})
+	if launched || res.ExitCode != 1 || len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Error(), "grun validate [1]") {
+		t.Errorf("parameters not provided must stop Run before launch: %+v", res)
+	}
+}
+
+func Test_dryRun(t *testing.T) {
+	var launched bool
+	var logged string
+	app := grun.New(grun.SetDryRun(), grun.SetPackPolicy(grun.PackBox))
+	app.Logf = func(format string, args ...interface{}) { logged += fmt.Sprintf(format, args...) }
+	res := app.RunResult(func() { launched = true }, []interface{}{"label", "next"}, errors.New("stop"))
+	if launched || res.ExitCode != 0 || app.App != nil {
+		t.Fatalf("dry run must not launch anything: %+v", res)
+	}
+	if !strings.HasPrefix(logged, "grun plan") || strings.HasSuffix(logged, "\n") {
+		t.Errorf("plan must be printed with Logf: %q", logged)
+	}
+	started := make(chan struct{})
+	app.Go(func(context.Context) error { close(started); return nil })
+	select {
+	case <-started:
+		t.Error("Go must be rejected after a dry run")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	var buf bytes.Buffer
+	app.Plan(&buf, Test_dryRun, []interface{}{"label", "next"}, errors.New("stop"))
+	for _, want := range []string{
+		"[0]", grun.KindHeadless, "grun_test.Test_dryRun",
+		"[1][0]", grun.KindWidget, "-> window (Win)", "-> box",
+		"[2]", grun.KindError,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("plan should contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func Test_orderedActions(t *testing.T) {
+	var order []string
+	add := func(name string) func() { return func() { order = append(order, name) } }
+	fail := func() error { return errors.New("fail") }
+
+	app := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless(), grun.SetSortedMaps())
+	res := app.RunResult(grun.Exit(0),
+		map[string]interface{}{"c": add("c"), "a": add("a"), "b": add("b")},
+		grun.OrderedActions{
+			{Name: "z", Action: add("z")},
+			{Name: "y", Action: map[string]interface{}{"sub": fail}},
+			{Name: "x", Action: add("x")},
+		},
+	)
+	if fmt.Sprint(order) != "[a b c z x]" {
+		t.Errorf("bad order: %v", order)
+	}
+	if res.ExitCode != 1 || len(res.Entries) != 7 {
+		t.Fatalf("bad entries: %+v", res)
+	}
+	if e := res.Entries[4]; e.Name != "y/sub" || e.Err == nil {
+		t.Errorf("bad nested entry: %+v", e)
+	}
+	if e := res.Entries[6]; e.Name != "x" || e.Err != nil {
+		t.Errorf("entries after an error must be launched: %+v", e)
+	}
+}
+
+func Test_errorPolicy(t *testing.T) {
+	fail := func() error { return errors.New("fail") }
+	var launched int
+	next := func() { launched++ }
+	calls := []interface{}{grun.Exit(0), fail, next, []interface{}{next, fail}, map[string]interface{}{"a": fail, "b": next}}
+
+	res := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless()).RunResult(calls...)
+	if launched != 0 || len(res.Errors) != 1 {
+		t.Errorf("default policy must stop on the first error: %d %v", launched, res.Errors)
+	}
+
+	launched = 0
+	app := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless(), grun.SetErrorPolicy(grun.ErrorCollect),
+		grun.SetOnRun(func() {}), // Doesn't shift the indexes.
+	)
+	res = app.RunResult(calls...)
+	if launched != 3 || len(res.Errors) != 3 || res.ExitCode != 1 {
+		t.Fatalf("collect policy must launch all Actions: %d %v", launched, res.Errors)
+	}
+	for i, want := range []string{"[1] ", "[3] ", "grun.Exec(a)"} {
+		if !strings.Contains(res.Errors[i].Error(), want) {
+			t.Errorf("error %d should be annotated with %q: %s", i, want, res.Errors[i])
+		}
+	}
+}
+
+func Test_errorsIsAs(t *testing.T) {
+	_, pathErr := os.Open("/missing/grun")
+	fail := func() error { return fmt.Errorf("load: %w", pathErr) }
+	stopped := false
+	app := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless(), grun.SetOnStop(func(*gtk.Application) { stopped = true }))
+	res := app.RunResult(map[string]interface{}{"load": fail, "db": grun.ExitWith(3, errors.New("locked"))})
+	if !errors.Is(res.Errors, fs.ErrNotExist) {
+		t.Errorf("wrapped error lost: %v", res.Errors)
+	}
+	var exitErr *grun.ExitError
+	if !errors.As(res.Errors, &exitErr) || res.ExitCode != 3 || !stopped {
+		t.Errorf("exit error not returned: %+v stopped: %v", res, stopped)
+	}
+
+	errs := grun.Errors{pathErr, fmt.Errorf("load: %w", pathErr), errors.New("other")}
+	if n := len(append(errs, pathErr).Dedup()); n != 3 {
+		t.Errorf("dedup should keep 3 errors, got %d", n)
+	}
+	if n := len(errs.Filter(func(e error) bool { return errors.Is(e, fs.ErrNotExist) })); n != 2 {
+		t.Errorf("filter should keep 2 errors, got %d", n)
+	}
+	if count := errs.CountByType(); count["*fs.PathError"] != 2 || count["*errors.errorString"] != 1 {
+		t.Errorf("bad count by type: %v", count)
+	}
+}
+
+func Test_errorDisplayHeadless(t *testing.T) {
+	t.Setenv(grun.EnvErrorDisplay, "dialog")
+	app := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless(), grun.SetErrorDisplay(grun.DisplayPanel))
+	res := app.RunResult(grun.After(time.Millisecond, errors.New("fail")), grun.Set(func(app *grun.App) { app.App.Hold() }))
+	if res.ExitCode != 1 || len(res.Errors) != 1 {
+		t.Errorf("headless errors must be printed and quit: %+v", res)
+	}
+}
+
+func Test_errorDialog(t *testing.T) {
+	var found bool
+	closeDialog := func(app *grun.App) {
+		for _, win := range app.App.Windows() {
+			if win.Title() == grun.TxtErrDialogTitle {
+				found = true
+				win.Destroy() // Quits.
+			}
+		}
+	}
+	app := grun.New(grun.SetFlagNonUnique(), grun.SetErrorDisplay(grun.DisplayDialog))
+	res := app.RunResult(grun.After(50*time.Millisecond, closeDialog), errors.New("fail"))
+	if res.ExitCode != 1 || !found {
+		t.Errorf("errors must be shown in a dialog: found=%t %+v", found, res)
+	}
+}
+
+func Test_errorPanel(t *testing.T) {
+	var running bool
+	app := grun.New(grun.SetFlagNonUnique(), grun.SetErrorDisplay(grun.DisplayPanel))
+	res := app.RunResult(
+		grun.After(50*time.Millisecond, func(app *grun.App) {
+			running = app.Win != nil && app.Win.Child() != nil
+			app.Exit(0)
+		}),
+		"content",
+		errors.New("fail"),
+	)
+	if res.ExitCode != 1 || len(res.Errors) != 1 || !running {
+		t.Errorf("errors must be shown in the window and keep running: running=%t %+v", running, res)
+	}
+}
+
+func Test_warnings(t *testing.T) {
+	var launched bool
+	app := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless())
+	res := app.RunResult(grun.Exit(0),
+		grun.Warn(errors.New("deprecated")),
+		func() error { return grun.Errors{errors.New("fail"), grun.Warn(errors.New("slow"))} },
+		func() { launched = true },
+	)
+	if len(res.Warnings) != 2 || len(app.Warnings()) != 2 {
+		t.Errorf("warnings not collected: %v", res.Warnings)
+	}
+	if launched || res.ExitCode != 1 || len(res.Errors) != 1 {
+		t.Errorf("errors beside warnings must stop Exec: %+v", res)
+	}
+}
+
+func Test_warningsWidget(t *testing.T) {
+	res := grun.New(grun.SetFlagNonUnique()).RunResult(grun.Exit(0),
+		func() (gtk.Widgetter, grun.Errors) {
+			return gtk.NewLabel("ui"), grun.Errors{grun.Warn(errors.New("deprecated"))}
+		},
+	)
+	if res.ExitCode != 0 || !res.WindowShown || len(res.Warnings) != 1 {
+		t.Errorf("widget with warnings must be packed: %+v", res)
+	}
+}
+
+func Test_locatedError(t *testing.T) {
+	errs := grun.Errors{fmt.Errorf("load: %w", &grun.LocatedError{File: "ui.xml", Line: 12, Column: 4, Err: fs.ErrInvalid})}
+	var located *grun.LocatedError
+	if !errors.As(errs, &located) || located.Location() != "ui.xml:12:4" || !errors.Is(errs, fs.ErrInvalid) {
+		t.Errorf("bad located error: %v", errs)
+	}
+	if msg := (&grun.LocatedError{File: "ui.xml", Line: 3, Err: fs.ErrInvalid}).Error(); msg != "ui.xml:3: invalid argument" {
+		t.Errorf("bad located error message: %s", msg)
+	}
+}
+
+func Test_parallel(t *testing.T) {
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var got string
+			res := grun.New(grun.SetFlagNonUnique(), grun.SetHeadless()).RunResult(func() { got = name })
+			if res.ExitCode != 0 || got != name {
+				t.Errorf("bad parallel run: %q %+v", got, res)
+			}
+		})
+	}
+}
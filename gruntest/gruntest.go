@@ -0,0 +1,132 @@
+// Package gruntest integrates grun applications with go tests.
+//
+// RunT runs the App in the test: its ID is named after the test, messages are
+// logged with t.Log, and errors, exit codes or timeouts fail the test.
+//
+// GTK must run on one OS thread: use grun.TestMain (or grun.TestMainWindows)
+// in the package TestMain to run parallel tests.
+//
+//   func TestMain(m *testing.M) { grun.TestMain(m) }
+//
+//   func TestHello(t *testing.T) {
+//     gruntest.RunT(t, grun.New(grun.SetHeadless()), func() error { return nil })
+//   }
+//
+package gruntest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/diamondburned/gotk4/pkg/gio/v2"
+
+	"github.com/gtkool4/grun"
+)
+
+// FmtID defines the application ID of tests.
+var FmtID = "com.github.gtkool4.gruntest.%s" // Format: test name
+
+// Format test errors messages.
+var (
+	FmtErrTimeout  = "gruntest: timeout after %s" // Format: duration
+	FmtErrExitCode = "gruntest: exit code %d"     // Format: code
+	FmtErrRun      = "gruntest: %s"               // Format: error
+	FmtWarnRun     = "gruntest: warning: %s"      // Format: warning
+)
+
+// DefaultTimeout defines the timeout of RunT when the test has no deadline.
+var DefaultTimeout = 30 * time.Second
+
+// DeadlineMargin is kept before the test deadline to report the timeout.
+var DeadlineMargin = time.Second
+
+// RunT runs the App with the Actions, and fails the test on errors, non-zero
+// exit codes, or timeout (see Timeout).
+//
+// The App ID is set after the test name when empty, and the application is
+// non-unique. Messages are logged with t.Log (see grun.Println). On timeout,
+// the test fails and the application is closed from the main loop (see
+// grun.App.Invoke). It's also closed on the test cleanup if still running.
+func RunT(t *testing.T, app *grun.App, calls ...interface{}) grun.Result {
+	t.Helper()
+	if app.ID == "" {
+		app.ID = ID(t)
+	}
+	app.Flags |= gio.ApplicationNonUnique
+	app.Logf = t.Logf
+
+	var (
+		mu       sync.Mutex
+		done     bool
+		timedOut bool
+	)
+	timeout := Timeout(t)
+	timer := time.AfterFunc(timeout, func() { // Outside the main loop, in case it's stuck.
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			return
+		}
+		timedOut = true
+		t.Errorf(FmtErrTimeout, timeout)
+		app.Invoke(grun.Exit(1))
+	})
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if !done {
+			app.Invoke(grun.Exit(1)) // On the main loop.
+		}
+	})
+	res := app.RunResult(calls...)
+	timer.Stop()
+	mu.Lock()
+	done = true
+	mu.Unlock()
+
+	for _, e := range res.Errors {
+		t.Errorf(FmtErrRun, e)
+	}
+	for _, w := range res.Warnings {
+		t.Logf(FmtWarnRun, w)
+	}
+	if !timedOut && !res.Errors.IsError() && res.ExitCode != 0 {
+		t.Errorf(FmtErrExitCode, res.ExitCode)
+	}
+	return res
+}
+
+// Timeout returns the time left before the test deadline, minus
+// DeadlineMargin, or DefaultTimeout without deadline.
+func Timeout(t *testing.T) time.Duration {
+	deadline, ok := t.Deadline()
+	if !ok {
+		return DefaultTimeout
+	}
+	if left := time.Until(deadline) - DeadlineMargin; left > 0 {
+		return left
+	}
+	return time.Millisecond
+}
+
+// ID returns a valid application ID made of the test name.
+func ID(t testing.TB) string {
+	elements := strings.Split(t.Name(), "/") // Subtests.
+	for i, elem := range elements {
+		elem = strings.Map(func(r rune) rune {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-') {
+				return r
+			}
+			return '_'
+		}, elem)
+		if elem == "" || unicode.IsDigit(rune(elem[0])) {
+			elem = "_" + elem
+		}
+		elements[i] = elem
+	}
+	return fmt.Sprintf(FmtID, strings.Join(elements, "."))
+}
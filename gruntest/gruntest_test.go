@@ -0,0 +1,28 @@
+package gruntest_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gtkool4/grun"
+	"github.com/gtkool4/grun/gruntest"
+)
+
+func TestMain(m *testing.M) { grun.TestMain(m) }
+
+func TestRunT(t *testing.T) {
+	app := grun.New(grun.SetHeadless())
+	var id string
+	res := gruntest.RunT(t, app, grun.Println("logged by t.Log"), grun.Warn(errors.New("warning")), func(app *grun.App) { id = app.ID })
+	if res.ExitCode != 0 || len(res.Warnings) != 1 || id != "com.github.gtkool4.gruntest.TestRunT" {
+		t.Errorf("bad run: %s %+v", id, res)
+	}
+}
+
+func TestID(t *testing.T) {
+	t.Run("1 sub/case", func(t *testing.T) {
+		if id := gruntest.ID(t); id != "com.github.gtkool4.gruntest.TestID._1_sub.case" {
+			t.Errorf("bad ID: %s", id)
+		}
+	})
+}
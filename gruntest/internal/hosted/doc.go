@@ -0,0 +1,4 @@
+// Package hosted tests the Apps hosted by grun.TestMainWindows.
+//
+// TestMain is set once per package: the hosted tests need their own.
+package hosted
@@ -0,0 +1,40 @@
+package hosted_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+
+	"github.com/gtkool4/grun"
+	"github.com/gtkool4/grun/gruntest"
+)
+
+func TestMain(m *testing.M) { grun.TestMainWindows(m) }
+
+func TestSequentialApps(t *testing.T) {
+	var stops int
+	onStop := grun.SetOnStop(func(*gtk.Application) { stops++ })
+
+	res := gruntest.RunT(t, grun.New(onStop), "window", grun.After(20*time.Millisecond, grun.Exit(0)))
+	if res.ExitCode != 0 || !res.WindowShown {
+		t.Errorf("bad window run: %+v", res)
+	}
+
+	var launched bool
+	res = gruntest.RunT(t, grun.New(onStop), func() { launched = true }) // Ends without window.
+	if res.ExitCode != 0 || !launched {
+		t.Errorf("bad second run: %+v", res)
+	}
+	if stops != 2 {
+		t.Errorf("each hosted App must be stopped, got %d", stops)
+	}
+}
+
+func TestGuessName(t *testing.T) {
+	app := grun.New(grun.SetGuessName(), grun.SetHeadless())
+	res := app.RunResult(func() {})
+	if res.ExitCode != 0 || app.ID != "com.github.gtkool4.default.internal.hosted" || app.FmtTitle != "internal/hosted" {
+		t.Errorf("name must be guessed from the caller package: %s %q %+v", app.ID, app.FmtTitle, res)
+	}
+}
@@ -0,0 +1,27 @@
+package grun
+
+// ActionFunc defines an Action launched by Exec, as seen by middlewares.
+type ActionFunc func(*App) error
+
+// Middleware defines a wrapper for every Action launched by Exec, including
+// Actions inside lists. It calls next to launch the Action.
+type Middleware func(next ActionFunc) ActionFunc
+
+// Use adds middlewares to wrap every Action launched by Exec.
+// The first middleware added is the outermost.
+func (app *App) Use(middlewares ...Middleware) {
+	app.middlewares = append(app.middlewares, middlewares...)
+}
+
+// CurrentAction returns the Action launched, to be used by middlewares.
+func (app *App) CurrentAction() interface{} { return app.current }
+
+//
+//------------------------------------------------------------------[ PARAMS ]--
+
+// SetUse creates a Param that adds middlewares to wrap every Action launched by
+// Exec.
+// Usable at any moment.
+func SetUse(middlewares ...Middleware) Param {
+	return func(app *App) { app.Use(middlewares...) }
+}
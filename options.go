@@ -0,0 +1,200 @@
+package grun
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/gio/v2"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+// Format options errors messages.
+var (
+	FmtErrOptionType  = "grun option %s: target type unknown: %T" // Format: name, interface{}
+	FmtErrOptionValue = "grun option %s: %w"                      // Format: name, error
+)
+
+// Default options descriptions.
+var (
+	TxtOptionHeadless  = "Run without window"
+	TxtOptionExitAfter = "Close the application after duration (2s, 1m...)"
+)
+
+// option defines a command line option entry and its Go target variable.
+type option struct {
+	name   string
+	short  byte
+	desc   string
+	target interface{} // *bool, *string, *int, *time.Duration, *[]string
+}
+
+// arg returns the glib option argument type matching the target.
+func (opt option) arg() (glib.OptionArg, error) {
+	switch opt.target.(type) {
+	case *bool:
+		return glib.OptionArgNone, nil
+	case *string, *time.Duration:
+		return glib.OptionArgString, nil
+	case *int:
+		return glib.OptionArgInt, nil
+	case *[]string:
+		return glib.OptionArgStringArray, nil
+	}
+	return 0, fmt.Errorf(FmtErrOptionType, opt.name, opt.target)
+}
+
+// read sets the target from the parsed options dict if the option was found.
+func (opt option) read(dict *glib.VariantDict) error {
+	if !dict.Contains(opt.name) {
+		return nil
+	}
+	value := dict.LookupValue(opt.name, nil)
+	switch target := opt.target.(type) {
+	case *bool:
+		*target = value.Boolean()
+
+	case *string:
+		_, *target = value.String()
+
+	case *int:
+		*target = int(value.Int32())
+
+	case *[]string:
+		*target = value.Strv()
+
+	case *time.Duration:
+		_, str := value.String()
+		d, e := time.ParseDuration(str)
+		if e != nil {
+			return fmt.Errorf(FmtErrOptionValue, opt.name, e)
+		}
+		*target = d
+	}
+	return nil
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// initOptions registers the options on App.App and connects their callbacks.
+func (app *App) initOptions() {
+	if app.OnCommandLine != nil || app.OnRemote != nil {
+		app.App.Connect("command-line", app.commandLine)
+	}
+	if len(app.options) == 0 {
+		return
+	}
+	for _, opt := range app.options {
+		arg, e := opt.arg()
+		if e != nil {
+			app.err = e
+			continue
+		}
+		app.App.AddMainOption(opt.name, opt.short, glib.OptionFlagNone, arg, opt.desc, "")
+	}
+	app.App.Connect("handle-local-options", app.localOptions)
+}
+
+// readOptions sets all options targets from the parsed options dict.
+func (app *App) readOptions(dict *glib.VariantDict) error {
+	for _, opt := range app.options {
+		if e := opt.read(dict); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// localOptions reads the options in the local instance, before the startup.
+// Returns -1 to continue the default processing, or 1 to exit on error.
+func (app *App) localOptions(_ *gtk.Application, dict *glib.VariantDict) int {
+	if app.err == nil {
+		app.err = app.readOptions(dict)
+	}
+	if app.err != nil {
+		return 1 // Printed by Run.
+	}
+	if app.exitAfter > 0 {
+		ExitAfter(app.exitAfter, 0)(app)
+	}
+	return -1
+}
+
+// commandLine calls the OnRemote callback for remote instances, or reads the
+// options sent to the primary instance and calls the OnCommandLine callback.
+// Its zero exit code activates the application.
+func (app *App) commandLine(_ *gtk.Application, cmdline *gio.ApplicationCommandLine) int {
+	if app.aborted {
+		return 1
+	}
+	if app.OnRemote != nil && cmdline.IsRemote() {
+		return app.remote(cmdline) // Options in CommandLine, targets unchanged.
+	}
+	if e := app.readOptions(cmdline.OptionsDict()); e != nil {
+		app.err = e
+		return 1
+	}
+	code := 0
+	if app.OnCommandLine != nil {
+		code = app.OnCommandLine(app, cmdline)
+	}
+	if code == 0 && !app.openArgs(cmdline) {
+		app.App.Activate()
+	}
+	return code
+}
+
+// openArgs forwards the files arguments of the command line to OnOpen, as the
+// open signal isn't emitted when the command line is handled.
+// Returns false if there's nothing to open.
+func (app *App) openArgs(cmdline *gio.ApplicationCommandLine) bool {
+	args := cmdline.Arguments()
+	if app.OnOpen == nil || len(args) < 2 {
+		return false
+	}
+	files := make([]gio.Filer, len(args)-1)
+	for i, arg := range args[1:] { // Skip the program name.
+		files[i] = cmdline.CreateFileForArg(arg)
+	}
+	app.App.Open(files, "")
+	return true
+}
+
+//
+//-----------------------------------------[ PARAMS - Only usable before Run ]--
+
+// SetOption creates a Param that declares a command line option.
+// The target must be a pointer to one of: bool, string, int, time.Duration,
+// []string. It's set when the command line is parsed, before OnInit.
+// The command line is read from Args, starting with the program name like
+// os.Args (see SetArgs).
+// A short name of 0 means no short name.
+// Only usable before Run.
+func SetOption(name string, short byte, description string, target interface{}) Param {
+	return func(app *App) {
+		app.options = append(app.options, option{name, short, description, target})
+	}
+}
+
+// SetOptionHeadless creates a Param that declares the --headless command line
+// option to set Headless.
+// Only usable before Run.
+func SetOptionHeadless() Param {
+	return func(app *App) { SetOption("headless", 0, TxtOptionHeadless, &app.Headless)(app) }
+}
+
+// SetOptionExitAfter creates a Param that declares the --exit-after command
+// line option to close the application after duration.
+// Only usable before Run.
+func SetOptionExitAfter() Param {
+	return func(app *App) { SetOption("exit-after", 0, TxtOptionExitAfter, &app.exitAfter)(app) }
+}
+
+// SetOnCommandLine creates a Param that sets the OnCommandLine callback.
+// Activates the gio.ApplicationHandlesCommandLine flag: files arguments are
+// forwarded to OnOpen when it returns 0.
+// Only usable before Run.
+func SetOnCommandLine(call func(app *App, cmdline *gio.ApplicationCommandLine) int) Param {
+	return func(app *App) { app.OnCommandLine = call }
+}
@@ -0,0 +1,90 @@
+package grun
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NamedAction defines an entry of OrderedActions.
+type NamedAction struct {
+	Name   string
+	Action interface{}
+}
+
+// OrderedActions defines a list of named Actions, launched in the list order.
+// Like maps, all entries are launched and their errors collected with their
+// names, unless ErrorPolicy is ErrorFailFast.
+type OrderedActions []NamedAction
+
+// Entry defines the result of a named Action, from a map or OrderedActions.
+type Entry struct {
+	Name     string        // Entry name, prefixed by its parents names: "parent/name".
+	Err      error         // Error returned by the Action.
+	Duration time.Duration // Time spent in the Action.
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// mapEntries converts the map to named Actions, sorted by names if SortedMaps
+// is set.
+func (app *App) mapEntries(list map[string]interface{}) OrderedActions {
+	entries := make(OrderedActions, 0, len(list))
+	for name, call := range list { // Warning, from a map, the order is random
+		entries = append(entries, NamedAction{name, call})
+	}
+	if app.SortedMaps {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	}
+	return entries
+}
+
+// execEntries launches the named Actions according to the ErrorPolicy.
+// Each entry result is added to the Result entries.
+func (app *App) execEntries(entries OrderedActions) error {
+	var errs Errors
+	for _, entry := range entries {
+		app.entryPath = append(app.entryPath, entry.Name)
+		start := time.Now()
+		e := app.exec(entry.Action)
+		app.result.Entries = append(app.result.Entries, Entry{
+			Name:     strings.Join(app.entryPath, "/"),
+			Err:      e,
+			Duration: time.Since(start),
+		})
+		app.entryPath = app.entryPath[:len(app.entryPath)-1]
+		if e == nil {
+			continue
+		}
+		app.setFailed(entry.Action)
+		switch app.ErrorPolicy {
+		case ErrorFailFast:
+			return fmt.Errorf(FmtErrExec, entry.Name, e)
+
+		case ErrorCollect:
+			errs.wrapErrors(e, FmtErrExec, entry.Name)
+
+		default:
+			errs.Append(fmt.Errorf(FmtErrExec, entry.Name, e))
+		}
+	}
+	switch {
+	case !errs.IsError():
+		return nil
+
+	case app.ErrorPolicy == ErrorCollect:
+		return errs
+	}
+	return errs.ToError()
+}
+
+//
+//------------------------------------------------------------------[ PARAMS ]--
+
+// SetSortedMaps creates a Param that launches map entries sorted by names.
+// Usable at any moment.
+func SetSortedMaps() Param {
+	return func(app *App) { app.SortedMaps = true }
+}
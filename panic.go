@@ -0,0 +1,51 @@
+package grun
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// FmtErrPanic formats the PanicError message.
+var FmtErrPanic = "grun.Exec(%s): panic: %v" // Format: Action name, recovered value
+
+// PanicError defines a panic recovered from an Action.
+type PanicError struct {
+	Action string      // Function name of the Action.
+	Value  interface{} // Recovered value.
+	Stack  []byte      // Stack trace of the panic.
+}
+
+// Error returns the panic message with the Action name. See Stack for details.
+func (e *PanicError) Error() string { return fmt.Sprintf(FmtErrPanic, e.Action, e.Value) }
+
+// Unwrap returns the recovered value if it's an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverPanic wraps the Action to return its panic as a PanicError.
+// The error is shown like others, according to the ErrorDisplay.
+func recoverPanic(next ActionFunc) ActionFunc {
+	return func(app *App) (e error) {
+		call := app.current
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			e = &PanicError{Action: ActionName(call), Value: r, Stack: debug.Stack()}
+		}()
+		return next(app)
+	}
+}
+
+//
+//------------------------------------------------------------------[ PARAMS ]--
+
+// SetRePanic creates a Param that disables the panic recovery in Actions, for
+// debugging.
+// Usable at any moment.
+func SetRePanic() Param {
+	return func(app *App) { app.RePanic = true }
+}
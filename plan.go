@@ -0,0 +1,136 @@
+package grun
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"text/tabwriter"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+// FmtPlanHeader defines the first line printed by the dry run.
+var FmtPlanHeader = "grun plan %s (dry run):" // Format: ID
+
+// Action kinds printed in the plan.
+const (
+	KindWidget      = "widget"
+	KindHeadless    = "headless"
+	KindList        = "list"
+	KindMap         = "map" // Random execution order, unless SortedMaps.
+	KindOrdered     = "ordered"
+	KindError       = "error"
+	KindCustom      = "custom" // Registered with RegisterActionType.
+	KindUnsupported = "unsupported"
+)
+
+// planPack describes where the next widgets go, by PackPolicy.
+var planPack = map[PackPolicy]string{
+	PackDrop:      "dropped",
+	PackBox:       "box",
+	PackStack:     "stack page",
+	PackNotebook:  "notebook page",
+	PackNewWindow: "new window",
+	PackError:     "error",
+}
+
+// Plan writes the execution plan of the Actions launched by Run: order, kind,
+// function names, and which Actions would get the window.
+// Nothing is launched, widgets could still be nil when running.
+func (app *App) Plan(w io.Writer, calls ...interface{}) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, FmtPlanHeader+"\n", app.ID)
+	var windows int
+	visit := func(path string, call interface{}) {
+		kind := ActionKind(call)
+		fmt.Fprintf(tw, "  %s\t%s\t%s", path, kind, ActionName(call))
+		if kind == KindWidget {
+			fmt.Fprintf(tw, "\t-> %s", app.planWindow(windows))
+			windows++
+		}
+		fmt.Fprintln(tw)
+	}
+	if app.OnInit != nil {
+		walk("OnInit", app.OnInit, visit)
+	}
+	for _, stage := range app.stages {
+		if stage.Init != nil {
+			walk("Stage("+stage.Name+").Init", stage.Init, visit)
+		}
+	}
+	if app.OnRun != nil {
+		walk("OnRun", app.OnRun, visit)
+	}
+	for i, call := range calls {
+		walk(fmt.Sprintf("[%d]", i), call, visit)
+	}
+	for i := len(app.stages) - 1; i >= 0; i-- {
+		if app.stages[i].Stop != nil {
+			walk("Stage("+app.stages[i].Name+").Stop", app.stages[i].Stop, visit)
+		}
+	}
+	tw.Flush()
+}
+
+// ActionKind returns the kind of the Action (KindWidget, KindHeadless...).
+func ActionKind(call interface{}) string {
+	switch call.(type) {
+	case []interface{}:
+		return KindList
+
+	case map[string]interface{}:
+		return KindMap
+
+	case OrderedActions:
+		return KindOrdered
+
+	case string, Window, chan gtk.Widgetter:
+		return KindWidget
+
+	case error:
+		return KindError
+	}
+
+	if !supported(call) {
+		return KindUnsupported
+	}
+	typ := reflect.TypeOf(call)
+	if typ.Kind() != reflect.Func {
+		return KindCustom
+	}
+	for i := 0; i < typ.NumOut(); i++ {
+		switch typ.Out(i) {
+		case reflect.TypeOf((*gtk.Widgetter)(nil)).Elem(), reflect.TypeOf(Window{}), reflect.TypeOf(""):
+			return KindWidget
+		}
+	}
+	return KindHeadless
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// planWindow describes where the widget number n would be packed.
+func (app *App) planWindow(n int) string {
+	switch {
+	case app.Headless:
+		return "dropped (headless)"
+
+	case n == 0:
+		return "window (Win)"
+
+	case app.MultiWindow:
+		return "new window"
+	}
+	return planPack[app.PackPolicy]
+}
+
+//
+//------------------------------------------------------------------[ PARAMS ]--
+
+// SetDryRun creates a Param that makes Run print the Actions plan without
+// creating the application.
+// Only usable before Run.
+func SetDryRun() Param {
+	return func(app *App) { app.DryRun = true }
+}
@@ -0,0 +1,133 @@
+package grun
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+// FmtErrProvide is returned when an Action parameter can't be resolved.
+var FmtErrProvide = "grun exec %s: no value provided for parameter type %s" // Format: name, type
+
+// Types resolved from the App.
+var (
+	typeApp     = reflect.TypeOf((*App)(nil))
+	typeGtkApp  = reflect.TypeOf((*gtk.Application)(nil))
+	typeGtkWin  = reflect.TypeOf((*gtk.ApplicationWindow)(nil))
+	typeContext = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// Provide adds values to inject in Actions parameters.
+//
+// Exec accepts any function with parameters resolved by type, and returns of
+// a registered Action type (none, gtk.Widgetter, error, Errors, Window...).
+// Parameters are resolved from the App (*App, *gtk.Application,
+// *gtk.ApplicationWindow, context.Context), then from the provided values by
+// exact type first, then by interface. The last provided value wins.
+// Run validates the parameters with the values provided before it starts.
+func (app *App) Provide(values ...interface{}) {
+	for _, value := range values {
+		if value != nil {
+			app.provided = append(app.provided, reflect.ValueOf(value))
+		}
+	}
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// injectAdapter returns an Adapter for functions with parameters to resolve,
+// or nil if the function returns are not a registered Action type.
+func injectAdapter(call interface{}) Adapter {
+	typ := reflect.TypeOf(call)
+	if typ == nil || typ.Kind() != reflect.Func || typ.IsVariadic() {
+		return nil
+	}
+	outs := make([]reflect.Type, typ.NumOut())
+	for i := range outs {
+		outs[i] = typ.Out(i)
+	}
+	bare := reflect.FuncOf(nil, outs, false) // Same returns without parameters.
+	adapt, ok := adapters.types[bare]
+	if !ok {
+		return nil
+	}
+	return func(call interface{}) ActionFunc {
+		return func(app *App) error {
+			args, e := app.resolve(call)
+			if e != nil {
+				return e
+			}
+			fn := reflect.ValueOf(call)
+			resolved := reflect.MakeFunc(bare, func([]reflect.Value) []reflect.Value { return fn.Call(args) })
+			return adapt(resolved.Interface())(app)
+		}
+	}
+}
+
+// resolve returns the values for the function parameters.
+func (app *App) resolve(call interface{}) ([]reflect.Value, error) {
+	typ := reflect.TypeOf(call)
+	args := make([]reflect.Value, typ.NumIn())
+	for i := range args {
+		arg, ok := app.lookup(typ.In(i))
+		if !ok {
+			return nil, fmt.Errorf(FmtErrProvide, ActionName(call), typ.In(i))
+		}
+		args[i] = arg
+	}
+	return args, nil
+}
+
+// lookup returns the value for the type.
+func (app *App) lookup(typ reflect.Type) (reflect.Value, bool) {
+	switch {
+	case typ == typeApp:
+		return reflect.ValueOf(app), true
+
+	case typ == typeGtkApp && app.App != nil:
+		return reflect.ValueOf(app.App), true
+
+	case typ == typeGtkWin && app.Win != nil:
+		return reflect.ValueOf(app.Win), true
+
+	case typ == typeContext:
+		return reflect.ValueOf(app.Context()), true
+	}
+
+	for i := len(app.provided) - 1; i >= 0; i-- {
+		if app.provided[i].Type() == typ {
+			return app.provided[i], true
+		}
+	}
+	if typ.Kind() == reflect.Interface {
+		for i := len(app.provided) - 1; i >= 0; i-- {
+			if app.provided[i].Type().Implements(typ) {
+				return app.provided[i].Convert(typ), true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// provides returns whether the parameter type can be resolved by Exec. The
+// App types are always resolved, even the ones that only exist at run time.
+func (app *App) provides(typ reflect.Type) bool {
+	switch typ {
+	case typeApp, typeGtkApp, typeGtkWin, typeContext:
+		return true
+	}
+	_, ok := app.lookup(typ)
+	return ok
+}
+
+//
+//------------------------------------------------------------------[ PARAMS ]--
+
+// SetProvide creates a Param that adds values to inject in Actions parameters.
+// Usable at any moment.
+func SetProvide(values ...interface{}) Param {
+	return func(app *App) { app.Provide(values...) }
+}
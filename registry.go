@@ -0,0 +1,352 @@
+package grun
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+// TxtErrRegisterNil is the panic message of RegisterActionType for an untyped
+// nil sample.
+var TxtErrRegisterNil = "grun.RegisterActionType: untyped nil sample, use a typed nil like (func())(nil)"
+
+// Adapter converts an Action of a registered type to an ActionFunc.
+type Adapter func(call interface{}) ActionFunc
+
+// adapters lists the registered Action types.
+var adapters = struct {
+	types  map[reflect.Type]Adapter
+	ifaces []ifaceAdapter // Matched in registration order, after types.
+}{types: make(map[reflect.Type]Adapter)}
+
+// ifaceAdapter defines an Adapter registered for an interface.
+type ifaceAdapter struct {
+	iface reflect.Type
+	adapt Adapter
+}
+
+// RegisterActionType teaches Exec a new Action type: the type of sample.
+// For an interface, use a nil pointer to it: (*MyInterface)(nil).
+// Interfaces are matched in their registration order, after the exact types.
+// Registering a type again replaces its Adapter.
+// Not safe for concurrent use, call it from init functions.
+// Panics on an untyped nil sample.
+func RegisterActionType(sample interface{}, adapt Adapter) {
+	typ := reflect.TypeOf(sample)
+	if typ == nil {
+		panic(TxtErrRegisterNil)
+	}
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Interface {
+		adapters.types[typ] = adapt
+		return
+	}
+	iface := typ.Elem()
+	for i := range adapters.ifaces {
+		if adapters.ifaces[i].iface == iface {
+			adapters.ifaces[i].adapt = adapt
+			return
+		}
+	}
+	adapters.ifaces = append(adapters.ifaces, ifaceAdapter{iface, adapt})
+}
+
+// adapterFor returns the Adapter registered for the Action type, or nil.
+func adapterFor(call interface{}) Adapter {
+	typ := reflect.TypeOf(call)
+	if typ == nil {
+		return nil
+	}
+	if adapt, ok := adapters.types[typ]; ok {
+		return adapt
+	}
+	for _, ia := range adapters.ifaces {
+		if typ.Implements(ia.iface) {
+			return ia.adapt
+		}
+	}
+	return nil
+}
+
+// dispatch launches one Action with the Adapter registered for its type, or
+// with its parameters resolved from the provided values.
+func (app *App) dispatch(call interface{}) error {
+	adapt := adapterFor(call)
+	if adapt == nil {
+		adapt = injectAdapter(call)
+	}
+	if adapt == nil {
+		return fmt.Errorf(FmtErrTypeUnknown, call)
+	}
+	return adapt(call)(app)
+}
+
+//
+//-----------------------------------------------------------[ ADAPTER TOOLS ]--
+
+// PackWithError packs the widget returned by the call, or returns its error.
+func (app *App) PackWithError(call func() (gtk.Widgetter, error)) error {
+	var e error
+	pe := app.Pack(func() gtk.Widgetter {
+		var w gtk.Widgetter
+		w, e = call()
+		if e != nil {
+			return nil
+		}
+		return w
+	})
+	if e != nil {
+		return e
+	}
+	return pe
+}
+
+// packErrors packs the widget unless the list has errors. The list is returned
+// with only warnings, to be collected.
+func (app *App) packErrors(call func() (gtk.Widgetter, Errors)) error {
+	var errs Errors
+	e := app.PackWithError(func() (gtk.Widgetter, error) {
+		var w gtk.Widgetter
+		w, errs = call()
+		if errs.IsError() {
+			return nil, errs.ToError()
+		}
+		return w, nil
+	})
+	if e == nil && len(errs) > 0 {
+		return errs.ToError()
+	}
+	return e
+}
+
+// headless converts a func without return to an ActionFunc.
+func headless(call func(app *App)) ActionFunc {
+	return func(app *App) error { call(app); return nil }
+}
+
+//
+//-------------------------------------------------------[ DEFAULT ADAPTERS ]--
+
+func init() {
+	//
+	// Widgets.
+
+	RegisterActionType((func() gtk.Widgetter)(nil), func(call interface{}) ActionFunc {
+		c := call.(func() gtk.Widgetter)
+		return func(app *App) error { return app.Pack(c) }
+	})
+
+	RegisterActionType((func(*App) gtk.Widgetter)(nil), func(call interface{}) ActionFunc {
+		c := call.(func(*App) gtk.Widgetter)
+		return func(app *App) error { return app.Pack(func() gtk.Widgetter { return c(app) }) }
+	})
+
+	RegisterActionType((func() (gtk.Widgetter, error))(nil), func(call interface{}) ActionFunc {
+		c := call.(func() (gtk.Widgetter, error))
+		return func(app *App) error { return app.PackWithError(c) }
+	})
+
+	RegisterActionType((func(*App) (gtk.Widgetter, error))(nil), func(call interface{}) ActionFunc {
+		c := call.(func(*App) (gtk.Widgetter, error))
+		return func(app *App) error {
+			return app.PackWithError(func() (gtk.Widgetter, error) { return c(app) })
+		}
+	})
+
+	// useful ???
+	RegisterActionType((chan gtk.Widgetter)(nil), func(call interface{}) ActionFunc {
+		c := call.(chan gtk.Widgetter)
+		return func(app *App) error {
+			return app.Pack(func() gtk.Widgetter { w := <-c; close(c); return w }) // <3
+		}
+	})
+
+	//
+	// Widgets with window settings.
+
+	RegisterActionType(Window{}, func(call interface{}) ActionFunc {
+		c := call.(Window)
+		return func(app *App) error { return app.PackWindow(func() Window { return c }) }
+	})
+
+	RegisterActionType((func() Window)(nil), func(call interface{}) ActionFunc {
+		c := call.(func() Window)
+		return func(app *App) error { return app.PackWindow(c) }
+	})
+
+	RegisterActionType((func(*App) Window)(nil), func(call interface{}) ActionFunc {
+		c := call.(func(*App) Window)
+		return func(app *App) error { return app.PackWindow(func() Window { return c(app) }) }
+	})
+
+	RegisterActionType((func(*App) (Window, error))(nil), func(call interface{}) ActionFunc {
+		c := call.(func(*App) (Window, error))
+		return func(app *App) error {
+			var e error
+			pe := app.PackWindow(func() Window {
+				var win Window
+				win, e = c(app)
+				if e != nil {
+					return Window{}
+				}
+				return win
+			})
+			if e != nil {
+				return e
+			}
+			return pe
+		}
+	})
+
+	//
+	// Errors: errors lists
+
+	RegisterActionType((func() (gtk.Widgetter, Errors))(nil), func(call interface{}) ActionFunc {
+		c := call.(func() (gtk.Widgetter, Errors))
+		return func(app *App) error { return app.packErrors(c) }
+	})
+
+	RegisterActionType((func(*App) (gtk.Widgetter, Errors))(nil), func(call interface{}) ActionFunc {
+		c := call.(func(*App) (gtk.Widgetter, Errors))
+		return func(app *App) error {
+			return app.packErrors(func() (gtk.Widgetter, Errors) { return c(app) })
+		}
+	})
+
+	//
+	// Headless.
+
+	RegisterActionType((func())(nil), func(call interface{}) ActionFunc {
+		c := call.(func())
+		return headless(func(*App) { c() })
+	})
+
+	RegisterActionType((func() error)(nil), func(call interface{}) ActionFunc {
+		c := call.(func() error)
+		return func(*App) error { return c() }
+	})
+
+	RegisterActionType(Param(nil), func(call interface{}) ActionFunc {
+		return headless(call.(Param))
+	})
+
+	RegisterActionType((func(*App))(nil), func(call interface{}) ActionFunc {
+		return headless(call.(func(*App)))
+	})
+
+	RegisterActionType((func(*App) error)(nil), func(call interface{}) ActionFunc {
+		return call.(func(*App) error)
+	})
+
+	RegisterActionType(ActionFunc(nil), func(call interface{}) ActionFunc {
+		return call.(ActionFunc)
+	})
+
+	RegisterActionType((func() func(*App))(nil), func(call interface{}) ActionFunc {
+		c := call.(func() func(*App))
+		return headless(func(app *App) { c()(app) })
+	})
+
+	RegisterActionType((func(*gtk.Application))(nil), func(call interface{}) ActionFunc {
+		c := call.(func(*gtk.Application))
+		return headless(func(app *App) { c(app.App) })
+	})
+
+	//
+	// With context, cancelled when the application is closing.
+
+	RegisterActionType((func(context.Context))(nil), func(call interface{}) ActionFunc {
+		c := call.(func(context.Context))
+		return headless(func(app *App) { c(app.Context()) })
+	})
+
+	RegisterActionType((func(context.Context) error)(nil), func(call interface{}) ActionFunc {
+		c := call.(func(context.Context) error)
+		return func(app *App) error { return c(app.Context()) }
+	})
+
+	RegisterActionType((func(context.Context, *App))(nil), func(call interface{}) ActionFunc {
+		c := call.(func(context.Context, *App))
+		return headless(func(app *App) { c(app.Context(), app) })
+	})
+
+	RegisterActionType((func(context.Context, *App) error)(nil), func(call interface{}) ActionFunc {
+		c := call.(func(context.Context, *App) error)
+		return func(app *App) error { return c(app.Context(), app) }
+	})
+
+	RegisterActionType((func(context.Context) gtk.Widgetter)(nil), func(call interface{}) ActionFunc {
+		c := call.(func(context.Context) gtk.Widgetter)
+		return func(app *App) error { return app.Pack(func() gtk.Widgetter { return c(app.Context()) }) }
+	})
+
+	RegisterActionType((func(context.Context) (gtk.Widgetter, error))(nil), func(call interface{}) ActionFunc {
+		c := call.(func(context.Context) (gtk.Widgetter, error))
+		return func(app *App) error {
+			return app.PackWithError(func() (gtk.Widgetter, error) { return c(app.Context()) })
+		}
+	})
+
+	RegisterActionType((func(context.Context, *App) (gtk.Widgetter, error))(nil), func(call interface{}) ActionFunc {
+		c := call.(func(context.Context, *App) (gtk.Widgetter, error))
+		return func(app *App) error {
+			return app.PackWithError(func() (gtk.Widgetter, error) { return c(app.Context(), app) })
+		}
+	})
+
+	//
+	// Lists.
+
+	RegisterActionType([]interface{}(nil), func(call interface{}) ActionFunc {
+		return Exec(call.([]interface{})...) // Recursion to allow any kind of crazy config.
+	})
+
+	RegisterActionType(map[string]interface{}(nil), func(call interface{}) ActionFunc {
+		c := call.(map[string]interface{})
+		return func(app *App) error { // Recursion... Déjà vu.
+			return app.execEntries(app.mapEntries(c)) // This is mostly for tests and serial queuing.
+		}
+	})
+
+	RegisterActionType(OrderedActions(nil), func(call interface{}) ActionFunc {
+		c := call.(OrderedActions)
+		return func(app *App) error { return app.execEntries(c) }
+	})
+
+	//
+	// String as label could be used for tests.
+
+	RegisterActionType("", func(call interface{}) ActionFunc {
+		c := call.(string)
+		return func(app *App) error { return app.Pack(func() gtk.Widgetter { return gtk.NewLabel(c) }) }
+	})
+
+	RegisterActionType((func() string)(nil), func(call interface{}) ActionFunc {
+		c := call.(func() string)
+		return func(app *App) error { return app.Pack(func() gtk.Widgetter { return gtk.NewLabel(c()) }) }
+	})
+
+	RegisterActionType((func(*App) string)(nil), func(call interface{}) ActionFunc {
+		c := call.(func(*App) string)
+		return func(app *App) error { return app.Pack(func() gtk.Widgetter { return gtk.NewLabel(c(app)) }) }
+	})
+
+	//
+	// Errors
+
+	RegisterActionType(Errors(nil), func(call interface{}) ActionFunc {
+		c := call.(Errors)
+		return func(*App) error {
+			if len(c) > 0 {
+				return c.ToError() // Warnings are collected.
+			}
+			return nil
+		}
+	})
+
+	RegisterActionType((*error)(nil), func(call interface{}) ActionFunc {
+		c := call.(error)
+		return func(*App) error { return c }
+	})
+}
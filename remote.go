@@ -0,0 +1,76 @@
+package grun
+
+import (
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/gio/v2"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+)
+
+// Format remote errors messages.
+var FmtErrRemote = "grun.Remote(%s): %s" // Format: args, error
+
+// CommandLine defines a command line received by the primary instance from
+// another launch of the application.
+type CommandLine struct {
+	Args     []string // Arguments, starting with the program name.
+	Cwd      string   // Working directory of the remote instance.
+	Environ  []string // Environment of the remote instance. Format: "KEY=value".
+	ExitCode int      // Exit code returned to the remote instance. Set to 1 on errors if 0.
+
+	Options *glib.VariantDict // Options of the remote instance. The Go targets of SetOption are not changed.
+}
+
+// NewCommandLine copies the gio command line data.
+func NewCommandLine(cmdline *gio.ApplicationCommandLine) *CommandLine {
+	return &CommandLine{
+		Args:    cmdline.Arguments(),
+		Cwd:     cmdline.Cwd(),
+		Environ: cmdline.Environ(),
+		Options: cmdline.OptionsDict(),
+	}
+}
+
+// Getenv returns the value of the remote environment variable.
+func (cmd *CommandLine) Getenv(key string) string {
+	for _, kv := range cmd.Environ {
+		if strings.HasPrefix(kv, key+"=") {
+			return kv[len(key)+1:]
+		}
+	}
+	return ""
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// remote launches the OnRemote Actions in the primary instance, and forwards
+// the files arguments to OnOpen if the ExitCode is 0.
+// Returns the exit code for the remote instance.
+func (app *App) remote(cmdline *gio.ApplicationCommandLine) int {
+	cmd := NewCommandLine(cmdline)
+	if calls := app.OnRemote(cmd); calls != nil {
+		if e := Exec(calls)(app); e != nil {
+			app.logf(FmtErrRemote, strings.Join(cmd.Args, " "), e)
+			if cmd.ExitCode == 0 {
+				cmd.ExitCode = 1
+			}
+		}
+	}
+	if cmd.ExitCode == 0 {
+		app.openArgs(cmdline)
+	}
+	return cmd.ExitCode
+}
+
+//
+//-----------------------------------------[ PARAMS - Only usable before Run ]--
+
+// SetOnRemote creates a Param that sets the OnRemote callback.
+// Activates the gio.ApplicationHandlesCommandLine and
+// gio.ApplicationSendEnvironment flags: files arguments are forwarded to OnOpen
+// when the ExitCode is 0.
+// Only usable before Run.
+func SetOnRemote(call func(cmd *CommandLine) interface{}) Param {
+	return func(app *App) { app.OnRemote = call }
+}
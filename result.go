@@ -0,0 +1,167 @@
+package grun
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+// Result defines the detailed result of Run.
+type Result struct {
+	ExitCode    int      // Returned by Run: ExitError code or 1 on errors, or GoExitCode, or GtkExitCode.
+	GoExitCode  int      // Set by Exit.
+	GtkExitCode int      // Returned by App.App.
+	Errors      Errors   // Errors from Exec, callbacks and goroutines.
+	Failed      string   // Name of the first Action that failed.
+	WindowShown bool     // A window was shown.
+	Phases      Phases   // Duration of each lifecycle phase.
+	Entries     []Entry  // Result of each named Action (maps and OrderedActions).
+	Warnings    Warnings // Non-fatal problems collected.
+}
+
+// Phases defines the duration of each lifecycle phase of Run.
+type Phases struct {
+	Startup  time.Duration // From Run to the end of the startup signal (OnInit).
+	Activate time.Duration // Exec of the Run Actions (activate or open signals).
+	Running  time.Duration // From the end of the first activation to the shutdown.
+	Shutdown time.Duration // Shutdown signal (OnStop).
+	Wait     time.Duration // Wait for the goroutines started with Go.
+	Total    time.Duration // Whole Run.
+}
+
+// RunResult starts the application like Run and returns the detailed result.
+// Errors are not printed.
+func (app *App) RunResult(calls ...interface{}) Result {
+	start := time.Now()
+	app.result = Result{}
+	app.runStart, app.started = start, time.Time{}
+	app.err, app.aborted, app.stopErrs, app.entryPath = nil, false, nil, nil
+	app.warnings, app.routines = nil, new(routines)
+	app.named = app.GuessName // Before Init, that can run from another stack.
+	if app.named {
+		app.guessName(packageName())
+	}
+	if app.DryRun {
+		var plan strings.Builder
+		app.Plan(&plan, calls...)
+		app.logf("%s", strings.TrimSuffix(plan.String(), "\n"))
+	}
+	if errs := app.validate(calls); errs.IsError() || app.DryRun {
+		if errs.IsError() {
+			app.result.Errors, app.result.ExitCode = errs, 1
+		}
+		app.closeRoutines()
+		app.result.Phases.Startup = time.Since(start)
+		app.result.Phases.Total = app.result.Phases.Startup
+		return app.result // Nothing launched.
+	}
+	activate := func(_ *gtk.Application) {
+		if app.aborted {
+			return
+		}
+		defer app.timePhase(&app.result.Phases.Activate, time.Now())
+		if e := app.execRun(calls); e != nil {
+			app.keepError(e)
+			app.showError(e)
+		}
+	}
+	if tests != nil {
+		app.result.GtkExitCode = tests.run(app, activate) // On the shared main thread.
+	} else {
+		app.Init(activate)
+		app.result.GtkExitCode = app.App.Run(app.Args)
+	}
+
+	waitStart := time.Now()
+	errs := app.waitRoutines()
+	app.result.Phases.Wait = time.Since(waitStart)
+
+	errs = append(app.stopErrs, errs...)
+	if list, ok := app.err.(Errors); ok {
+		errs = append(list, errs...)
+	} else if app.err != nil {
+		errs = append(Errors{app.err}, errs...)
+	}
+	res := app.result
+	res.Errors = errs
+	res.Warnings = app.warnings
+	res.GoExitCode = app.ExitCode()
+	var exitErr *ExitError
+	switch {
+	case errors.As(errs, &exitErr):
+		res.ExitCode = exitErr.Code
+
+	case errs.IsError():
+		res.ExitCode = 1
+	case res.GoExitCode != 0:
+		res.ExitCode = res.GoExitCode
+	default:
+		res.ExitCode = res.GtkExitCode
+	}
+	if res.Phases.Startup == 0 {
+		res.Phases.Startup = time.Since(start) // Stopped before startup.
+	}
+	res.Phases.Total = time.Since(start)
+	return res
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// timePhase adds the time elapsed since start to the phase.
+// The end of the first activation starts the running phase.
+func (app *App) timePhase(phase *time.Duration, start time.Time) {
+	*phase += time.Since(start)
+	if phase == &app.result.Phases.Activate && app.started.IsZero() {
+		app.started = time.Now()
+	}
+}
+
+// execRun launches OnRun then the Run Actions. Errors paths match Validate and
+// Plan: OnRun, then [index] of the Run Actions.
+func (app *App) execRun(calls []interface{}) error {
+	if app.OnRun == nil {
+		return app.execList(calls, nil)
+	}
+	paths := []string{"OnRun"}
+	for i := range calls {
+		paths = append(paths, fmt.Sprintf("[%d]", i))
+	}
+	return app.execList(append([]interface{}{app.OnRun}, calls...), paths)
+}
+
+// keepError stores the error returned by Run, unless one is already stored:
+// the application can keep running after errors (DisplayPanel).
+func (app *App) keepError(e error) {
+	if app.err == nil {
+		app.err = e
+	}
+}
+
+// setFailed stores the name of the first Action that failed.
+// Lists are skipped as the failed Action inside was already stored.
+func (app *App) setFailed(call interface{}) {
+	switch call.(type) {
+	case []interface{}, map[string]interface{}, OrderedActions:
+		return
+	}
+	if app.result.Failed == "" {
+		app.result.Failed = ActionName(call)
+	}
+}
+
+// ActionName returns the function name of the Action, or its type.
+func ActionName(call interface{}) string {
+	v := reflect.ValueOf(call)
+	if v.Kind() == reflect.Func && !v.IsNil() {
+		if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	return fmt.Sprintf("%T", call)
+}
@@ -0,0 +1,147 @@
+package grun
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	externglib "github.com/diamondburned/gotk4/pkg/core/glib"
+)
+
+// DefaultShutdownTimeout defines how long Run waits for goroutines started
+// with Go when ShutdownTimeout is not set.
+var DefaultShutdownTimeout = 5 * time.Second
+
+// TxtErrShutdownTimeout is returned when goroutines are still running after
+// the shutdown timeout.
+var TxtErrShutdownTimeout = "grun.Go: shutdown timeout, goroutines still running"
+
+// TxtErrGoClosed is printed when Go is called out of Run, or after shutdown.
+var TxtErrGoClosed = "grun.Go: application not running, goroutine not started"
+
+// routines tracks the goroutines started with Go during one Run.
+type routines struct {
+	wg     sync.WaitGroup
+	mu     sync.Mutex // Protects errs, closed and wg.Add.
+	errs   Errors
+	closed bool // Waiting or done, Go is rejected.
+}
+
+// Go starts a goroutine tracked by the App. Its context is cancelled when the
+// application is closing, and Run waits for it until ShutdownTimeout.
+// The returned error is added to the Run errors.
+// Out of Run, or after the shutdown, the goroutine is not started.
+// Safe to call from any goroutine.
+func (app *App) Go(call func(ctx context.Context) error) {
+	app.goStart(call, false)
+}
+
+// GoHold starts a goroutine like Go, and holds the application until it
+// returns so it keeps running without window.
+// Must be called from the GTK main loop (Actions).
+func (app *App) GoHold(call func(ctx context.Context) error) {
+	app.goStart(call, true)
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// goStart starts the tracked goroutine, unless the routines are closed.
+func (app *App) goStart(call func(ctx context.Context) error, hold bool) {
+	r := app.routines
+	if r == nil {
+		app.logf("%s", TxtErrGoClosed)
+		return
+	}
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		app.logf("%s", TxtErrGoClosed)
+		return
+	}
+	r.wg.Add(1) // Under the lock, to never race with Wait.
+	r.mu.Unlock()
+
+	gtkapp := app.App
+	hold = hold && app.hosted == nil // The shared application of tests isn't held.
+	if hold {
+		gtkapp.Hold()
+	}
+	ctx := app.Context()
+	go func() {
+		defer r.wg.Done()
+		if hold {
+			defer externglib.IdleAdd(func() {
+				if !r.isClosed() { // Not after the shutdown, Quit ignores the hold.
+					gtkapp.Release()
+				}
+			})
+		}
+		if e := call(ctx); e != nil {
+			r.mu.Lock()
+			r.errs.Append(e)
+			r.mu.Unlock()
+		}
+	}()
+}
+
+// closeRoutines rejects the next calls to Go.
+func (app *App) closeRoutines() {
+	if r := app.routines; r != nil {
+		r.mu.Lock()
+		r.closed = true
+		r.mu.Unlock()
+	}
+}
+
+// isClosed returns true when Go is rejected.
+func (r *routines) isClosed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closed
+}
+
+// waitRoutines cancels the goroutines, closes the routines and waits until
+// they return or the shutdown timeout. Returns their errors.
+// Goroutines still running are dropped with the routines of this Run.
+func (app *App) waitRoutines() Errors {
+	app.cancelContext()
+	r := app.routines
+	if r == nil {
+		return nil
+	}
+	app.closeRoutines()
+	done := make(chan struct{})
+	go func() { r.wg.Wait(); close(done) }()
+
+	timeout := app.ShutdownTimeout
+	if timeout == 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	var timedOut bool
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		timedOut = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	errs := r.errs
+	r.errs = nil
+	if timedOut {
+		errs.Append(errors.New(TxtErrShutdownTimeout))
+	}
+	return errs
+}
+
+//
+//------------------------------------------------------------------[ PARAMS ]--
+
+// SetShutdownTimeout creates a Param that sets how long Run waits for the
+// goroutines started with Go.
+// Usable at any moment.
+func SetShutdownTimeout(d time.Duration) Param {
+	return func(app *App) { app.ShutdownTimeout = d }
+}
@@ -0,0 +1,76 @@
+package grun
+
+import (
+	"time"
+
+	externglib "github.com/diamondburned/gotk4/pkg/core/glib"
+)
+
+// Invoke launches the Action on the GTK main loop (idle source).
+// Safe to call from any goroutine. Errors stop the application like on Run.
+func (app *App) Invoke(call Action) {
+	externglib.IdleAdd(func() { app.execAsync(call) })
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// execAsync launches an Action from the main loop, after Run's Actions.
+// The first error is stored to be returned by Run, and closes the application
+// unless it's shown (ErrorDisplay).
+func (app *App) execAsync(call Action) error {
+	e := Exec(call)(app)
+	if e != nil {
+		app.keepError(e)
+		if !app.showError(e) && app.App != nil {
+			app.quit()
+		}
+	}
+	return e
+}
+
+// timeout launches the Action on the GTK main loop after duration, repeated
+// until it returns an error when every is set. The source is removed on
+// shutdown. Before the application is created (Params), the timeout is added
+// on startup.
+func (app *App) timeout(d time.Duration, every bool, call Action) {
+	if app.App == nil {
+		app.timeouts = append(app.timeouts, func() { app.timeout(d, every, call) })
+		return
+	}
+	if app.sources == nil {
+		app.sources = make(map[externglib.SourceHandle]struct{})
+	}
+	var h externglib.SourceHandle
+	h = externglib.TimeoutAdd(uint(d/time.Millisecond), func() bool {
+		if app.execAsync(call) != nil || !every {
+			delete(app.sources, h)
+			return false
+		}
+		return true
+	})
+	app.sources[h] = struct{}{}
+}
+
+// removeSources removes the timeout sources still pending.
+func (app *App) removeSources() {
+	for h := range app.sources {
+		externglib.SourceRemove(h)
+	}
+	app.sources = nil
+}
+
+//
+//-----------------------------------------------------------------[ ACTIONS ]--
+
+// After creates an Action that launches the Action on the GTK main loop after
+// duration.
+func After(d time.Duration, call Action) Action {
+	return func(app *App) { app.timeout(d, false, call) }
+}
+
+// Every creates an Action that launches the Action on the GTK main loop every
+// duration, until it returns an error or the application is closing.
+func Every(d time.Duration, call Action) Action {
+	return func(app *App) { app.timeout(d, true, call) }
+}
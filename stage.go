@@ -0,0 +1,120 @@
+package grun
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format stages errors messages.
+var (
+	FmtErrStage        = "grun stage %s: %w"                    // Format: name, error
+	FmtErrStageUnknown = "grun stage %s: unknown dependency %s" // Format: name, dependency
+	FmtErrStageDouble  = "grun stage %s: declared twice"        // Format: name
+	FmtErrStageCycle   = "grun stages dependency cycle: %s"     // Format: names
+)
+
+// Stage defines a named startup step, launched after OnInit and before OnRun.
+// Stages are launched after their dependencies, in the declaration order
+// when possible. Stop Actions are launched in reverse order at shutdown, only
+// for stages that succeeded.
+type Stage struct {
+	Name  string
+	After []string    // Names of the stages to launch before.
+	Init  interface{} // Actions launched at startup. An error aborts the startup.
+	Stop  interface{} // Actions launched at shutdown, before OnStop.
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// initStages launches the stages Init Actions in dependency order.
+func (app *App) initStages() error {
+	sorted, e := sortStages(app.stages)
+	if e != nil {
+		return e
+	}
+	for _, stage := range sorted {
+		if stage.Init != nil {
+			if e := Exec(stage.Init)(app); e != nil {
+				return fmt.Errorf(FmtErrStage, stage.Name, e)
+			}
+		}
+		app.stagesUp = append(app.stagesUp, stage)
+	}
+	return nil
+}
+
+// stopStages launches the Stop Actions of the stages started, in reverse order.
+func (app *App) stopStages() {
+	for i := len(app.stagesUp) - 1; i >= 0; i-- {
+		stage := app.stagesUp[i]
+		if stage.Stop == nil {
+			continue
+		}
+		if e := Exec(stage.Stop)(app); e != nil {
+			app.stopErrs.Append(fmt.Errorf(FmtErrStage, stage.Name, e))
+		}
+	}
+	app.stagesUp = nil
+}
+
+// sortStages returns the stages in dependency order, keeping the declaration
+// order when possible.
+func sortStages(stages []Stage) ([]Stage, error) {
+	names := make(map[string]bool, len(stages))
+	for _, stage := range stages {
+		if names[stage.Name] {
+			return nil, fmt.Errorf(FmtErrStageDouble, stage.Name)
+		}
+		names[stage.Name] = true
+	}
+	for _, stage := range stages {
+		for _, dep := range stage.After {
+			if !names[dep] {
+				return nil, fmt.Errorf(FmtErrStageUnknown, stage.Name, dep)
+			}
+		}
+	}
+
+	done := make(map[string]bool, len(stages))
+	ready := func(stage Stage) bool {
+		for _, dep := range stage.After {
+			if !done[dep] {
+				return false
+			}
+		}
+		return true
+	}
+
+	sorted := make([]Stage, 0, len(stages))
+	for len(sorted) < len(stages) {
+		found := false
+		for _, stage := range stages {
+			if !done[stage.Name] && ready(stage) {
+				sorted = append(sorted, stage)
+				done[stage.Name] = true
+				found = true
+				break // Restart to keep the declaration order.
+			}
+		}
+		if !found {
+			var left []string
+			for _, stage := range stages {
+				if !done[stage.Name] {
+					left = append(left, stage.Name)
+				}
+			}
+			return nil, fmt.Errorf(FmtErrStageCycle, strings.Join(left, ", "))
+		}
+	}
+	return sorted, nil
+}
+
+//
+//-----------------------------------------[ PARAMS - Only usable before Run ]--
+
+// SetStages creates a Param that adds startup stages.
+// Only usable before Run.
+func SetStages(stages ...Stage) Param {
+	return func(app *App) { app.stages = append(app.stages, stages...) }
+}
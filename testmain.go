@@ -0,0 +1,125 @@
+package grun
+
+import (
+	"os"
+	"runtime"
+
+	externglib "github.com/diamondburned/gotk4/pkg/core/glib"
+	"github.com/diamondburned/gotk4/pkg/gio/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+// TestMainID defines the ID of the shared application of TestMainWindows.
+var TestMainID = "com.github.gtkool4.grun.testmain"
+
+// TestingM defines the tests runner, *testing.M.
+type TestingM interface{ Run() int }
+
+// tests is the shared GTK main thread set by TestMain, or nil.
+var tests *testLoop
+
+// testLoop runs the Apps of tests on the main thread.
+type testLoop struct {
+	jobs chan func()      // Serialised Runs.
+	host *gtk.Application // Shared application of concurrent windows.
+}
+
+// TestMain runs the tests with a shared GTK main thread: Apps runs are
+// submitted to the thread running TestMain, locked, and serialised, so tests
+// can be parallel.
+//
+//   func TestMain(m *testing.M) { grun.TestMain(m) }
+//
+// The locked thread is the main OS thread only if it was locked before, from
+// an init function of the test package. GTK requires it on macOS:
+//
+//   func init() { runtime.LockOSThread() }
+//
+func TestMain(m TestingM) {
+	runtime.LockOSThread()
+	tests = &testLoop{jobs: make(chan func())}
+	code := make(chan int, 1)
+	go func() {
+		code <- m.Run()
+		close(tests.jobs)
+	}()
+	for job := range tests.jobs {
+		job()
+	}
+	os.Exit(<-code)
+}
+
+// TestMainWindows runs the tests with a shared GTK main loop: Apps are hosted
+// by a single application, and run concurrently with their own windows.
+//
+// Hosted Apps end when their windows are closed, on Exit or errors, or after
+// their Actions if no window was opened. Command line options and OnOpen are
+// not handled.
+//
+//   func TestMain(m *testing.M) { grun.TestMainWindows(m) }
+//
+// Like TestMain, the main OS thread must be locked from an init function for
+// GTK on macOS.
+func TestMainWindows(m TestingM) {
+	runtime.LockOSThread()
+	tests = &testLoop{host: gtk.NewApplication(TestMainID, gio.ApplicationNonUnique)}
+	code := make(chan int, 1)
+	tests.host.Connect("activate", func() {
+		tests.host.Hold()
+		go func() {
+			code <- m.Run()
+			externglib.IdleAdd(tests.host.Release)
+		}()
+	})
+	tests.host.Run(nil)
+	os.Exit(<-code)
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// run runs the App on the main thread and waits until it ends.
+// Returns the GTK exit code.
+func (loop *testLoop) run(app *App, activate func(*gtk.Application)) int {
+	done := make(chan int, 1)
+	if loop.host == nil {
+		loop.jobs <- func() {
+			app.Init(activate)
+			done <- app.App.Run(app.Args)
+		}
+		return <-done
+	}
+	app.hosted, app.hostEnd = done, false
+	externglib.IdleAdd(func() { app.host(loop.host, activate) })
+	return <-done
+}
+
+// host starts the App in the shared application.
+func (app *App) host(gtkapp *gtk.Application, activate func(*gtk.Application)) {
+	app.initContext()
+	app.App = gtkapp
+	app.startup(gtkapp)
+	activate(gtkapp)
+	if app.aborted || len(app.wins) == 0 {
+		app.quit()
+	}
+}
+
+// quit closes the application. When hosted by TestMainWindows, only the App
+// ends: its windows are closed and shutdown is called on the next idle.
+func (app *App) quit() {
+	switch {
+	case app.hosted == nil:
+		app.App.Quit()
+
+	case !app.hostEnd:
+		app.hostEnd = true
+		externglib.IdleAdd(func() {
+			for _, win := range app.wins {
+				win.Destroy()
+			}
+			app.shutdown(app.App)
+			app.hosted <- 0
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package grun
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// FmtErrValidate is returned for each unsupported Action found by Validate.
+var FmtErrValidate = "grun validate %s: unsupported Action type %T" // Format: path, interface{}
+
+// FmtErrValidateProvide is returned by Run for each Action parameter that
+// can't be resolved (see Provide).
+var FmtErrValidateProvide = "grun validate %s: no value provided for parameter type %s" // Format: path, type
+
+// Validate walks the Actions tree, including nested lists and maps, and
+// returns an error for every unsupported type, with its path in the tree:
+//   [2].map["load"][0]
+//
+// Run validates its Actions (OnInit, stages, OnRun and arguments) before
+// starting the application.
+func Validate(calls ...interface{}) Errors {
+	var errs Errors
+	for i, call := range calls {
+		validate(&errs, "["+strconv.Itoa(i)+"]", call, nil)
+	}
+	return errs
+}
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// walk visits the Action and its children, with path as its location.
+// Map entries are visited in their names order.
+func walk(path string, call interface{}, visit func(path string, call interface{})) {
+	visit(path, call)
+	switch list := call.(type) {
+	case []interface{}:
+		for i, recall := range list {
+			walk(path+"["+strconv.Itoa(i)+"]", recall, visit)
+		}
+
+	case map[string]interface{}:
+		names := make([]string, 0, len(list))
+		for name := range list {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			walk(path+".map["+strconv.Quote(name)+"]", list[name], visit)
+		}
+
+	case OrderedActions:
+		for _, entry := range list {
+			walk(path+".ordered["+strconv.Quote(entry.Name)+"]", entry.Action, visit)
+		}
+	}
+}
+
+// supported returns whether Exec can launch the Action.
+func supported(call interface{}) bool {
+	switch call.(type) {
+	case []interface{}, map[string]interface{}, OrderedActions:
+		return true
+	}
+	return adapterFor(call) != nil || injectAdapter(call) != nil
+}
+
+// validate checks the Action and its children, with path as its location.
+// With an app, the parameters of functions to inject are checked too.
+func validate(errs *Errors, path string, call interface{}, app *App) {
+	walk(path, call, func(path string, call interface{}) {
+		switch {
+		case !supported(call):
+			errs.Append(fmt.Errorf(FmtErrValidate, path, call))
+
+		case app != nil && adapterFor(call) == nil && injectAdapter(call) != nil:
+			typ := reflect.TypeOf(call)
+			for i := 0; i < typ.NumIn(); i++ {
+				if !app.provides(typ.In(i)) {
+					errs.Append(fmt.Errorf(FmtErrValidateProvide, path, typ.In(i)))
+				}
+			}
+		}
+	})
+}
+
+// validate checks all the Actions launched by Run, and the parameters to
+// inject with the values provided before Run.
+func (app *App) validate(calls []interface{}) Errors {
+	var errs Errors
+	if app.OnInit != nil {
+		validate(&errs, "OnInit", app.OnInit, app)
+	}
+	for _, stage := range app.stages {
+		if stage.Init != nil {
+			validate(&errs, "Stage("+stage.Name+").Init", stage.Init, app)
+		}
+		if stage.Stop != nil {
+			validate(&errs, "Stage("+stage.Name+").Stop", stage.Stop, app)
+		}
+	}
+	if app.OnRun != nil {
+		validate(&errs, "OnRun", app.OnRun, app)
+	}
+	for i, call := range calls {
+		validate(&errs, "["+strconv.Itoa(i)+"]", call, app)
+	}
+	return errs
+}
@@ -0,0 +1,92 @@
+package grun
+
+import (
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+// FmtWarnRun formats the warnings printed by Run.
+var FmtWarnRun = "grun.Run warnings: %s" // Format: warnings
+
+// Warnings define a list of non-fatal problems. Returned by an Action (or in
+// an Errors list), they are collected and Exec continues.
+type Warnings []error
+
+// Error returns the list of warnings as string.
+func (w Warnings) Error() string { return Errors(w).Error() }
+
+// Warn returns warnings to be returned by an Action. Usable as an Action.
+func Warn(warnings ...error) error { return Warnings(warnings) }
+
+// Warn adds warnings, shown in the warnings bar if WarningBar is set.
+// Must be called from the GTK main loop (Actions).
+func (app *App) Warn(warnings ...error) {
+	app.warnings = append(app.warnings, warnings...)
+	app.showWarnings()
+}
+
+// Warnings returns the warnings collected.
+func (app *App) Warnings() Warnings { return app.warnings }
+
+//
+//-----------------------------------------------------------[ INTERNAL WORK ]--
+
+// keepWarnings collects the warnings from the Action error, and returns the
+// remaining errors.
+func (app *App) keepWarnings(e error) error {
+	switch list := e.(type) {
+	case Warnings:
+		app.Warn(list...)
+		return nil
+
+	case Errors:
+		var errs Errors
+		for _, err := range list {
+			if warnings, ok := err.(Warnings); ok {
+				app.Warn(warnings...)
+			} else {
+				errs = append(errs, err)
+			}
+		}
+		if !errs.IsError() {
+			return nil
+		}
+		return errs
+	}
+	return e
+}
+
+// packWarningBar sets the window content with a warnings bar at the top.
+// Next contents are set with setContent.
+func (app *App) packWarningBar(win *gtk.ApplicationWindow) {
+	label := gtk.NewLabel("")
+	bar := gtk.NewInfoBar()
+	bar.SetMessageType(gtk.MessageWarning)
+	bar.SetShowCloseButton(true)
+	bar.AddChild(label)
+	bar.Connect("response", func(_ int) { bar.SetRevealed(false) })
+
+	app.packed.bar, app.packed.barLabel = bar, label
+	app.packed.barBox = gtk.NewBox(gtk.OrientationVertical, 0)
+	app.packed.barBox.Append(bar)
+	win.SetChild(app.packed.barBox)
+	app.showWarnings()
+}
+
+// showWarnings updates the warnings bar.
+func (app *App) showWarnings() {
+	if app.packed.bar == nil {
+		return
+	}
+	app.packed.barLabel.SetText(app.warnings.Error())
+	app.packed.bar.SetRevealed(len(app.warnings) > 0)
+}
+
+//
+//------------------------------------------------------------------[ PARAMS ]--
+
+// SetWarningBar creates a Param that shows the warnings in an info bar at the
+// top of the window.
+// Only usable before Run.
+func SetWarningBar() Param {
+	return func(app *App) { app.WarningBar = true }
+}
@@ -0,0 +1,171 @@
+package grun
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+// FmtWinName defines the window default name in the registry.
+var FmtWinName = "win%d" // Format: window number
+
+// FmtPageName defines the page default name and title for stack and notebook.
+var FmtPageName = "page%d" // Format: page number
+
+// TxtErrPacked is returned by the PackError policy.
+var TxtErrPacked = "grun.Pack: window already packed"
+
+// PackPolicy defines how to pack widgets found after the first window is
+// opened (without MultiWindow).
+type PackPolicy int
+
+// Pack policies.
+const (
+	PackDrop      PackPolicy = iota // Drop the widget (default).
+	PackBox                         // Append in a vertical box.
+	PackStack                       // Add a stack page with a switcher.
+	PackNotebook                    // Add a notebook page.
+	PackNewWindow                   // Open a new window.
+	PackError                       // Return an error (the Action is launched, its widget dropped).
+)
+
+// Window defines a widget with its window settings.
+// Empty settings use the App values.
+type Window struct {
+	Name   string // Name in the windows registry. Default: FmtWinName.
+	Title  string // Window title.
+	Width  int    // Window width.
+	Height int    // Window height.
+	Widget gtk.Widgetter
+}
+
+// packed defines the packing state of Win for the PackPolicy.
+type packed struct {
+	first    Window        // First widget packed in Win.
+	box      *gtk.Box      // PackBox container.
+	stack    *gtk.Stack    // PackStack container.
+	notebook *gtk.Notebook // PackNotebook container.
+	pages    int           // Number of pages in the stack or notebook.
+	bar      *gtk.InfoBar  // WarningBar.
+	barLabel *gtk.Label    // WarningBar text.
+	barBox   *gtk.Box      // WarningBar and content container.
+	content  gtk.Widgetter // Content below the WarningBar.
+}
+
+// Window returns the registered window by name, or nil.
+func (app *App) Window(name string) *gtk.ApplicationWindow {
+	return app.wins[name]
+}
+
+// Windows returns a copy of the windows registry.
+func (app *App) Windows() map[string]*gtk.ApplicationWindow {
+	list := make(map[string]*gtk.ApplicationWindow, len(app.wins))
+	for name, win := range app.wins {
+		list[name] = win
+	}
+	return list
+}
+
+// register adds the window to the registry until it's destroyed.
+func (app *App) register(name string, win *gtk.ApplicationWindow) {
+	app.winCount++
+	if name == "" {
+		name = fmt.Sprintf(FmtWinName, app.winCount)
+	}
+	if app.wins == nil {
+		app.wins = make(map[string]*gtk.ApplicationWindow)
+	}
+	app.wins[name] = win
+	win.Connect("destroy", func() {
+		if app.wins[name] == win {
+			delete(app.wins, name)
+		}
+		if app.hosted != nil && len(app.wins) == 0 {
+			app.quit() // Last window of an App hosted by TestMainWindows.
+		}
+	})
+}
+
+// packMore packs a widget in Win when it already has one, with the PackPolicy.
+// The first widget is moved to the container when the second is packed.
+func (app *App) packMore(call func() Window) error {
+	switch app.PackPolicy {
+	case PackDrop:
+		call()
+		return nil
+
+	case PackError:
+		call() // Same side effects as other policies, the widget is dropped.
+		return errors.New(TxtErrPacked)
+	}
+
+	w := call()
+	if w.Widget == nil {
+		return nil
+	}
+
+	switch app.PackPolicy {
+	case PackBox:
+		if app.packed.box == nil {
+			app.packed.box = gtk.NewBox(gtk.OrientationVertical, 0)
+			app.setContent(nil)
+			app.packed.box.Append(app.packed.first.Widget)
+			app.setContent(app.packed.box)
+		}
+		app.packed.box.Append(w.Widget)
+
+	case PackStack:
+		if app.packed.stack == nil {
+			app.packed.stack = gtk.NewStack()
+			app.packed.stack.SetVExpand(true)
+			switcher := gtk.NewStackSwitcher()
+			switcher.SetStack(app.packed.stack)
+			box := gtk.NewBox(gtk.OrientationVertical, 0)
+			box.Append(switcher)
+			box.Append(app.packed.stack)
+			app.setContent(nil)
+			app.addPage(app.packed.first)
+			app.setContent(box)
+		}
+		app.addPage(w)
+
+	case PackNotebook:
+		if app.packed.notebook == nil {
+			app.packed.notebook = gtk.NewNotebook()
+			app.setContent(nil)
+			app.addPage(app.packed.first)
+			app.setContent(app.packed.notebook)
+		}
+		app.addPage(w)
+	}
+	return nil
+}
+
+// setContent sets the Win content, below the WarningBar if set.
+func (app *App) setContent(w gtk.Widgetter) {
+	if app.packed.barBox == nil {
+		app.Win.SetChild(w)
+		return
+	}
+	if app.packed.content != nil {
+		app.packed.barBox.Remove(app.packed.content)
+	}
+	app.packed.content = w
+	if w != nil {
+		app.packed.barBox.Append(w)
+	}
+}
+
+// addPage adds the widget as a page of the stack or notebook container.
+func (app *App) addPage(w Window) {
+	app.packed.pages++
+	name := firstNonEmpty(w.Name, fmt.Sprintf(FmtPageName, app.packed.pages))
+	title := firstNonEmpty(w.Title, name)
+
+	if app.packed.stack != nil {
+		app.packed.stack.AddTitled(w.Widget, name, title)
+		return
+	}
+	app.packed.notebook.AppendPage(w.Widget, gtk.NewLabel(title))
+}